@@ -0,0 +1,86 @@
+package api
+
+import (
+	"net/http"
+)
+
+// Command is a request sent by the server to the run loop of the service on its control channel
+type Command int
+
+const (
+	// Stop asks the service to shut down
+	Stop Command = iota + 1
+	// Reload asks the service to reload its configuration, as SIGHUP does
+	Reload
+	// Drain asks the service to report itself as unhealthy, so that it is taken out of rotation by a load
+	// balancer, while it carries on serving the requests it receives
+	Drain
+)
+
+func (c Command) String() string {
+	switch c {
+	case Stop:
+		return "stop"
+	case Reload:
+		return "reload"
+	case Drain:
+		return "drain"
+	default:
+		return "unknown"
+	}
+}
+
+// Control is a message sent on the control channel of the server
+type Control struct {
+	Command Command
+	// Reason describes why the message was sent, it is logged by the run loop
+	Reason string
+	// Err is the error that made the server stop the service, the process exits with a non-zero status
+	Err error
+	// Status is the exit status of the process requested by a Stop, 0 exits successfully unless Err is set
+	Status int
+}
+
+// Send sends a control message to the run loop of the service, it returns false without waiting if the
+// server has been shut down, as the run loop no longer receives messages, or it has no control channel
+func (s *Server) Send(c Control) bool {
+	if s.control == nil {
+		return false
+	}
+
+	select {
+	case s.control <- c:
+		return true
+	case <-s.done:
+		return false
+	}
+}
+
+// ControlHandler returns an http.Handler that sends the command to the run loop of the service, it responds with
+// 202 Accepted once the run loop has received it, or 503 Service Unavailable if the service is not running
+func (s *Server) ControlHandler(command Command) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.Send(Control{Command: command, Reason: r.Method + " " + r.URL.Path}) {
+			RespondWithError(w, http.StatusServiceUnavailable, "the service is not running")
+			return
+		}
+
+		RespondWithJSON(w, http.StatusAccepted, map[string]string{"command": command.String()})
+	})
+}
+
+// SetDraining sets whether the service is draining, the health endpoint reports a draining service as down
+func (s *Server) SetDraining(draining bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.draining = draining
+}
+
+// Draining returns whether the service is draining
+func (s *Server) Draining() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.draining
+}
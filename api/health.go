@@ -0,0 +1,153 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// HealthUp is the status of a healthy service or check
+	HealthUp = "up"
+	// HealthDown is the status of an unhealthy service or check
+	HealthDown = "down"
+
+	// DefaultHealthCheckTimeout is the time allowed for all of the health checks to complete
+	DefaultHealthCheckTimeout = 5 * time.Second
+)
+
+// HealthCheck returns an error if a dependency of the service, such as a database, is unhealthy. The check
+// should return once the context is done.
+type HealthCheck func(ctx context.Context) error
+
+// HealthStatus is the status of the service reported by the health endpoint
+type HealthStatus struct {
+	Status string `json:"status"`
+	// Draining is set while the service is draining, or shutting down, it is then reported as down
+	Draining bool                   `json:"draining,omitempty"`
+	Checks   map[string]CheckStatus `json:"checks,omitempty"`
+}
+
+// CheckStatus is the status of a single health check
+type CheckStatus struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type healthChecks struct {
+	lock   sync.RWMutex
+	checks map[string]HealthCheck
+}
+
+// AddHealthCheck adds a check to the health endpoint, the service is reported as unhealthy if any of its
+// checks fails. Adding a check with the name of an existing check replaces it.
+func (s *Server) AddHealthCheck(name string, check HealthCheck) {
+	s.health.lock.Lock()
+	defer s.health.lock.Unlock()
+
+	if s.health.checks == nil {
+		s.health.checks = make(map[string]HealthCheck)
+	}
+
+	s.health.checks[name] = check
+}
+
+// Health runs the health checks concurrently and returns the status of the service
+func (s *Server) Health(ctx context.Context) HealthStatus {
+	s.health.lock.RLock()
+
+	names := make([]string, 0, len(s.health.checks))
+
+	for name := range s.health.checks {
+		names = append(names, name)
+	}
+
+	checks := make(map[string]HealthCheck, len(names))
+
+	for name, check := range s.health.checks {
+		checks[name] = check
+	}
+
+	s.health.lock.RUnlock()
+
+	sort.Strings(names)
+
+	ctx, cancel := context.WithTimeout(ctx, DefaultHealthCheckTimeout)
+	defer cancel()
+
+	results := make([]CheckStatus, len(names))
+
+	var wg sync.WaitGroup
+
+	for i, name := range names {
+		wg.Add(1)
+
+		go func(i int, check HealthCheck) {
+			defer wg.Done()
+			results[i] = runCheck(ctx, check)
+		}(i, checks[name])
+	}
+
+	wg.Wait()
+
+	status := HealthStatus{Status: HealthUp}
+
+	if s.Draining() {
+		status.Status, status.Draining = HealthDown, true
+	}
+
+	if len(names) > 0 {
+		status.Checks = make(map[string]CheckStatus, len(names))
+	}
+
+	for i, name := range names {
+		status.Checks[name] = results[i]
+
+		if results[i].Status == HealthDown {
+			status.Status = HealthDown
+		}
+	}
+
+	return status
+}
+
+// runCheck runs a check, a check that does not return before the context is done is reported as down
+func runCheck(ctx context.Context, check HealthCheck) CheckStatus {
+	done := make(chan error, 1)
+
+	go func() {
+		done <- check(ctx)
+	}()
+
+	var err error
+
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	if err != nil {
+		return CheckStatus{Status: HealthDown, Error: err.Error()}
+	}
+
+	return CheckStatus{Status: HealthUp}
+}
+
+// HealthHandler returns an http.Handler that reports the status of the service as JSON, it responds with
+// 200 OK if the service is healthy and 503 Service Unavailable otherwise
+func (s *Server) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := s.Health(r.Context())
+
+		code := http.StatusOK
+
+		if status.Status != HealthUp {
+			code = http.StatusServiceUnavailable
+		}
+
+		RespondWithJSON(w, code, status)
+	})
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHealthHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		checks     map[string]HealthCheck
+		cancelled  bool
+		wantCode   int
+		wantStatus string
+		wantChecks map[string]string
+	}{
+		{"Test no checks", nil, false, http.StatusOK, HealthUp, nil},
+		{
+			"Test healthy checks",
+			map[string]HealthCheck{
+				"db":    func(ctx context.Context) error { return nil },
+				"cache": func(ctx context.Context) error { return nil },
+			},
+			false, http.StatusOK, HealthUp, map[string]string{"db": HealthUp, "cache": HealthUp},
+		},
+		{
+			"Test failed check",
+			map[string]HealthCheck{
+				"db":    func(ctx context.Context) error { return errors.New("connection refused") },
+				"cache": func(ctx context.Context) error { return nil },
+			},
+			false, http.StatusServiceUnavailable, HealthDown, map[string]string{"db": HealthDown, "cache": HealthUp},
+		},
+		{
+			"Test cancelled check",
+			map[string]HealthCheck{
+				"slow": func(ctx context.Context) error { <-ctx.Done(); return ctx.Err() },
+			},
+			true, http.StatusServiceUnavailable, HealthDown, map[string]string{"slow": HealthDown},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New("localhost", 0, nil)
+
+			for name, check := range tt.checks {
+				s.AddHealthCheck(name, check)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			if tt.cancelled {
+				cancel()
+			}
+
+			w := httptest.NewRecorder()
+			s.HealthHandler().ServeHTTP(w, httptest.NewRequest("GET", "/health", nil).WithContext(ctx))
+
+			if w.Code != tt.wantCode {
+				t.Errorf("code = %d, want %d", w.Code, tt.wantCode)
+			}
+
+			status := s.Health(ctx)
+
+			if status.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", status.Status, tt.wantStatus)
+			}
+
+			if len(status.Checks) != len(tt.wantChecks) {
+				t.Fatalf("checks = %v, want %v", status.Checks, tt.wantChecks)
+			}
+
+			for name, want := range tt.wantChecks {
+				if got := status.Checks[name]; got.Status != want || (want == HealthDown) != (got.Error != "") {
+					t.Errorf("check %s = %+v, want status %q", name, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/birchwood-langham/web-service-bootstrap/logger"
+)
+
+const (
+	// RequestIDHeader is the header carrying the ID of a request, an ID received from the client is used
+	// if it is valid, otherwise a new one is generated, the ID is returned in the response header
+	RequestIDHeader = "X-Request-ID"
+	// TraceParentHeader is the W3C trace context header, see https://www.w3.org/TR/trace-context/
+	TraceParentHeader = "traceparent"
+
+	maxRequestIDLength = 128
+)
+
+type requestIDKey struct{}
+
+// RequestID returns the ID of the request handled with the context, or an empty string if the request
+// has not passed through the RequestContext middleware
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// RequestContext is a middleware that adds the request ID, and the trace ID if the request is part of a trace,
+// to the logger carried by the context of the request, so that they are included in every entry logged with
+// logger.FromContext(r.Context()) while the request is handled. It is added to the Router by Initialize.
+func RequestContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		w.Header().Set(RequestIDHeader, id)
+
+		fields := []zap.Field{zap.String("request_id", id)}
+
+		if traceID, ok := traceID(r.Header.Get(TraceParentHeader)); ok {
+			fields = append(fields, zap.String("trace_id", traceID))
+		}
+
+		ctx := context.WithValue(r.Context(), requestIDKey{}, id)
+		ctx = logger.WithContext(ctx, fields...)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// validRequestID reports whether a request ID received from a client can be used, it must be
+// made of printable ASCII characters so that it cannot be used to forge log entries
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	return strings.IndexFunc(id, func(r rune) bool {
+		return r <= ' ' || r > '~'
+	}) < 0
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+
+	return hex.EncodeToString(b)
+}
+
+// traceID returns the trace ID of a traceparent header, e.g. 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+func traceID(traceParent string) (string, bool) {
+	parts := strings.Split(strings.TrimSpace(traceParent), "-")
+
+	if len(parts) < 4 || len(parts[1]) != 32 || parts[1] == strings.Repeat("0", 32) {
+		return "", false
+	}
+
+	if _, err := hex.DecodeString(parts[1]); err != nil {
+		return "", false
+	}
+
+	return strings.ToLower(parts[1]), true
+}
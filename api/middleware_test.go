@@ -0,0 +1,85 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+
+	"github.com/birchwood-langham/web-service-bootstrap/logger"
+	"github.com/birchwood-langham/web-service-bootstrap/logger/logtest"
+)
+
+func TestRequestContext(t *testing.T) {
+	tests := []struct {
+		name        string
+		requestID   string
+		traceParent string
+		wantID      string
+		wantTraceID string
+	}{
+		{"Test generated request ID", "", "", "", ""},
+		{"Test client request ID", "client-id-1", "", "client-id-1", ""},
+		{"Test invalid client request ID", "bad id\nforged", "", "", ""},
+		{"Test trace ID", "id", "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", "id", "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{"Test invalid traceparent", "id", "00-xyz-00f067aa0ba902b7-01", "id", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := logtest.Capture(t)
+
+			var handlerID string
+
+			handler := RequestContext(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				handlerID = RequestID(r.Context())
+				logger.FromContext(r.Context()).Info("handled")
+			}))
+
+			req := httptest.NewRequest("GET", "/hello", nil)
+
+			if tt.requestID != "" {
+				req.Header.Set(RequestIDHeader, tt.requestID)
+			}
+
+			if tt.traceParent != "" {
+				req.Header.Set(TraceParentHeader, tt.traceParent)
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			id := w.Header().Get(RequestIDHeader)
+
+			if tt.wantID != "" && id != tt.wantID {
+				t.Errorf("request ID = %q, want %q", id, tt.wantID)
+			}
+
+			if id == "" || strings.ContainsAny(id, " \n") {
+				t.Errorf("invalid request ID %q", id)
+			}
+
+			if handlerID != id {
+				t.Errorf("handler request ID = %q, want %q", handlerID, id)
+			}
+
+			entries := logs.FilterLevel(zapcore.InfoLevel).All()
+
+			if len(entries) != 1 {
+				t.Fatalf("got %d entries, want 1", len(entries))
+			}
+
+			fields := entries[0].ContextMap()
+
+			if fields["request_id"] != id {
+				t.Errorf("logged request_id = %v, want %s", fields["request_id"], id)
+			}
+
+			if traceID, _ := fields["trace_id"].(string); traceID != tt.wantTraceID {
+				t.Errorf("logged trace_id = %q, want %q", traceID, tt.wantTraceID)
+			}
+		})
+	}
+}
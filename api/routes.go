@@ -0,0 +1,86 @@
+package api
+
+import (
+	"github.com/gorilla/mux"
+
+	"github.com/birchwood-langham/web-service-bootstrap/config"
+)
+
+// RouteInfo describes a route registered with the server
+type RouteInfo struct {
+	// Server is service for the routes of the Router, or admin for the administrative endpoints
+	Server string `json:"server" yaml:"server"`
+	// Methods are the HTTP methods matched by the route, all methods are matched if it is empty
+	Methods []string `json:"methods,omitempty" yaml:"methods,omitempty"`
+	// Path is the path template of the route, e.g. /users/{id}
+	Path string `json:"path,omitempty" yaml:"path,omitempty"`
+	// Name is the name given to the route with Route.Name
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// Host is the host template matched by the route
+	Host string `json:"host,omitempty" yaml:"host,omitempty"`
+	// Queries are the query templates matched by the route, e.g. page={page}
+	Queries []string `json:"queries,omitempty" yaml:"queries,omitempty"`
+	// Middleware are the names of the middleware added with Use that are applied to the route
+	Middleware []string `json:"middleware,omitempty" yaml:"middleware,omitempty"`
+}
+
+// Routes returns the routes registered with the Router and, if the administrative endpoints are enabled,
+// the Admin router, in the order they were registered. The server must have been initialized.
+func (s *Server) Routes() ([]RouteInfo, error) {
+	var routes []RouteInfo
+
+	err := s.Router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		// the administrative endpoints are listed from the Admin router
+		if route == s.adminMount {
+			return mux.SkipRouter
+		}
+
+		return appendRoute(&routes, "service", route, s.middleware)
+	})
+
+	if err != nil || !s.source.GetBool(config.AdminEnabledKey) {
+		return routes, err
+	}
+
+	server := "service"
+	middleware := s.middleware
+
+	if s.source.GetInt(config.AdminPortKey) != 0 {
+		server, middleware = "admin", nil
+	}
+
+	err = s.Admin.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		return appendRoute(&routes, server, route, middleware)
+	})
+
+	return routes, err
+}
+
+func appendRoute(routes *[]RouteInfo, server string, route *mux.Route, middleware []string) error {
+	// routes without a handler only group the routes of a subrouter
+	if route.GetHandler() == nil {
+		return nil
+	}
+
+	info := RouteInfo{
+		Server:     server,
+		Name:       route.GetName(),
+		Middleware: middleware,
+	}
+
+	// the getters return an error if the route does not have the matcher
+	info.Path, _ = route.GetPathTemplate()
+	info.Host, _ = route.GetHostTemplate()
+
+	if methods, err := route.GetMethods(); err == nil && len(methods) > 0 {
+		info.Methods = methods
+	}
+
+	if queries, err := route.GetQueriesTemplates(); err == nil && len(queries) > 0 {
+		info.Queries = queries
+	}
+
+	*routes = append(*routes, info)
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/viper"
+
+	"github.com/birchwood-langham/web-service-bootstrap/config"
+)
+
+func TestRoutes(t *testing.T) {
+	noop := func(http.ResponseWriter, *http.Request) {}
+
+	initializeRoutes := func(s *Server) {
+		s.Use("Auth", func(next http.Handler) http.Handler { return next })
+		s.Router.HandleFunc("/users/{id}", noop).Methods(http.MethodGet, http.MethodPut).Name("user")
+		s.Router.HandleFunc("/search", noop).Queries("q", "{q}").Host("api.example.com")
+
+		api := s.Router.PathPrefix("/v2").Subrouter()
+		api.HandleFunc("/items", noop).Methods(http.MethodPost)
+
+		s.Admin.HandleFunc("/cache", noop).Methods(http.MethodDelete)
+	}
+
+	middleware := []string{"RequestContext", "Auth"}
+
+	serviceRoutes := []RouteInfo{
+		{Server: "service", Methods: []string{"GET", "PUT"}, Path: "/users/{id}", Name: "user", Middleware: middleware},
+		{Server: "service", Path: "/search", Host: "api.example.com", Queries: []string{"q={q}"}, Middleware: middleware},
+		{Server: "service", Methods: []string{"POST"}, Path: "/v2/items", Middleware: middleware},
+	}
+
+	tests := []struct {
+		name     string
+		settings map[string]interface{}
+		want     []RouteInfo
+	}{
+		{"Test admin disabled", map[string]interface{}{}, serviceRoutes},
+		{"Test admin on service port", map[string]interface{}{config.AdminEnabledKey: true}, append(serviceRoutes[:3:3],
+			RouteInfo{Server: "service", Methods: []string{"DELETE"}, Path: "/admin/cache", Middleware: middleware})},
+		{"Test admin on admin port", map[string]interface{}{config.AdminEnabledKey: true, config.AdminPortKey: 8990}, append(serviceRoutes[:3:3],
+			RouteInfo{Server: "admin", Methods: []string{"DELETE"}, Path: "/admin/cache"})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+			defer viper.Reset()
+
+			for k, v := range tt.settings {
+				viper.Set(k, v)
+			}
+
+			s := New("localhost", 8989, nil)
+			s.Initialize(initializeRoutes)
+
+			got, err := s.Routes()
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Routes() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,41 +1,131 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/birchwood-langham/web-service-bootstrap/config"
 	"github.com/gorilla/mux"
-	"github.com/spf13/viper"
 	"go.uber.org/zap"
 )
 
+// AdminPathPrefix is the path prefix of the administrative endpoints
+const AdminPathPrefix = "/admin"
+
 // Server represents the Http Server we are creating to provide the web service we are building
 type Server struct {
-	Router         *mux.Router
-	messageChannel chan struct{}
-	host           string
-	port           int
-	server         *http.Server
+	Router *mux.Router
+	// Admin is the router for the administrative endpoints, its routes are served under AdminPathPrefix
+	// on the service port, or on the admin port if one has been configured. The administrative endpoints
+	// are only served if they have been enabled in the configuration.
+	Admin         *mux.Router
+	adminRouter   *mux.Router
+	adminMount    *mux.Route
+	middleware    []string
+	health        healthChecks
+	control       chan Control
+	done          chan struct{}
+	source        *config.Source
+	host          string
+	port          int
+	lock          sync.Mutex
+	server        *http.Server
+	listener      net.Listener
+	adminServer   *http.Server
+	adminListener net.Listener
+	shutdown      bool
+	draining      bool
+	err           error
 }
 
-// New creates a new api.Server instance running on the given host and port
+// New creates a new api.Server instance running on the given host and port, configured by the global configuration
+// source, the server sends a message on the message channel when it cannot serve and the service must be stopped.
+// The other control messages are discarded, use NewWithControl to receive them.
 func New(hostname string, port int, messageChannel chan struct{}) *Server {
+	if messageChannel == nil {
+		return NewWithControl(hostname, port, nil)
+	}
+
+	control := make(chan Control)
+	s := NewWithControl(hostname, port, control)
+
+	go func() {
+		for {
+			select {
+			case c := <-control:
+				if c.Command == Stop {
+					messageChannel <- struct{}{}
+				}
+			case <-s.done:
+				return
+			}
+		}
+	}()
+
+	return s
+}
+
+// NewWithControl creates a new api.Server instance running on the given host and port, configured by the global
+// configuration source, the server sends its control messages, such as a request to stop the service, on the
+// control channel
+func NewWithControl(hostname string, port int, control chan Control) *Server {
+	return NewWithSource(config.Global(), hostname, port, control)
+}
+
+// NewWithSource creates a new api.Server instance running on the given host and port, configured by the given source
+func NewWithSource(source *config.Source, hostname string, port int, control chan Control) *Server {
 	return &Server{
-		host:           hostname,
-		port:           port,
-		messageChannel: messageChannel,
+		host:    hostname,
+		port:    port,
+		control: control,
+		done:    make(chan struct{}),
+		source:  source,
 	}
 }
 
+// Config returns the configuration source of the server, so that the routes of the application can be configured
+// from the same source as the server
+func (s *Server) Config() *config.Source {
+	return s.source
+}
+
 // Initialize sets up the routes you want for your API server
 func (s *Server) Initialize(initializeRoutes func(*Server)) {
 	s.Router = mux.NewRouter()
+	s.Use("RequestContext", RequestContext)
+	s.adminRouter = mux.NewRouter()
+	s.Admin = s.adminRouter.PathPrefix(AdminPathPrefix).Subrouter()
+
+	if s.source.GetBool(config.AdminEnabledKey) && s.source.GetInt(config.AdminPortKey) == 0 {
+		s.adminMount = s.Router.PathPrefix(AdminPathPrefix).Handler(s.adminRouter)
+	}
+
 	initializeRoutes(s)
 }
 
+// Use adds a middleware to the Router, the name of the middleware is listed by the routes command.
+// Middleware added directly to the Router with Router.Use is applied but not listed.
+func (s *Server) Use(name string, middleware mux.MiddlewareFunc) {
+	s.Router.Use(middleware)
+	s.middleware = append(s.middleware, name)
+}
+
+// Mount returns a router for the routes under the path prefix, e.g. the routes of a module of the application,
+// the routes are served with the middleware added with Use and listed by the routes command with their full path
+func (s *Server) Mount(prefix string) *mux.Router {
+	if prefix == "" || prefix == "/" {
+		return s.Router
+	}
+
+	return s.Router.PathPrefix(prefix).Subrouter()
+}
+
 // RespondWithError wraps an error message as a JSON structure and returns it as a Http Response
 func RespondWithError(w http.ResponseWriter, code int, message string) {
 	RespondWithJSON(w, code, map[string]string{"error": message})
@@ -52,25 +142,43 @@ func RespondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	}
 }
 
-// Run launches you server
+// Run launches you server, it returns once the server has been shut down or could not be started
 func (s *Server) Run() {
+	if err := s.Listen(); err != nil {
+		s.fail("service", err)
+		return
+	}
+
+	s.Serve()
+}
+
+// Listen binds the service port, and the admin port if one has been configured, without serving requests,
+// so that the address of the server is known before it starts serving, e.g. when it listens on port 0
+func (s *Server) Listen() error {
 	writeTimeout := config.DefaultWriteTimeout
 	readTimeout := config.DefaultReadTimeout
 	idleTimeout := config.DefaultIdleTimeout
 
-	if viper.IsSet(config.ServiceWriteTimeoutKey) {
-		writeTimeout = viper.GetInt(config.ServiceWriteTimeoutKey)
+	if s.source.IsSet(config.ServiceWriteTimeoutKey) {
+		writeTimeout = s.source.GetInt(config.ServiceWriteTimeoutKey)
 	}
 
-	if viper.IsSet(config.ServiceReadTimeoutKey) {
-		readTimeout = viper.GetInt(config.ServiceReadTimeoutKey)
+	if s.source.IsSet(config.ServiceReadTimeoutKey) {
+		readTimeout = s.source.GetInt(config.ServiceReadTimeoutKey)
 	}
 
-	if viper.IsSet(config.ServiceIdleTimeoutKey) {
-		idleTimeout = viper.GetInt(config.ServiceIdleTimeoutKey)
+	if s.source.IsSet(config.ServiceIdleTimeoutKey) {
+		idleTimeout = s.source.GetInt(config.ServiceIdleTimeoutKey)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.shutdown {
+		return http.ErrServerClosed
 	}
 
-	s.server = &http.Server{
+	server := &http.Server{
 		Addr:         fmt.Sprintf("%s:%d", s.host, s.port),
 		WriteTimeout: time.Second * time.Duration(writeTimeout),
 		ReadTimeout:  time.Second * time.Duration(readTimeout),
@@ -78,15 +186,153 @@ func (s *Server) Run() {
 		Handler:      s.Router,
 	}
 
-	if err := s.server.ListenAndServe(); err != nil {
-		serviceName := "Unspecified"
+	listener, err := net.Listen("tcp", server.Addr)
+
+	if err != nil {
+		return err
+	}
+
+	if adminPort := s.source.GetInt(config.AdminPortKey); s.source.GetBool(config.AdminEnabledKey) && adminPort != 0 {
+		adminServer := &http.Server{
+			Addr:         fmt.Sprintf("%s:%d", s.host, adminPort),
+			WriteTimeout: server.WriteTimeout,
+			ReadTimeout:  server.ReadTimeout,
+			IdleTimeout:  server.IdleTimeout,
+			Handler:      s.adminRouter,
+		}
+
+		adminListener, err := net.Listen("tcp", adminServer.Addr)
 
-		if viper.IsSet(config.ServiceNameKey) {
-			serviceName = viper.GetString(config.ServiceNameKey)
+		if err != nil {
+			_ = listener.Close()
+			return fmt.Errorf("could not listen on the admin port: %w", err)
 		}
 
-		zap.S().Errorf("Could not start %s service: %v\n", serviceName, err)
-		// controlled stop by sending a stop message to the main thread
-		s.messageChannel <- struct{}{}
+		s.adminServer, s.adminListener = adminServer, adminListener
 	}
+
+	s.server, s.listener = server, listener
+
+	return nil
+}
+
+// Serve serves requests on the ports bound by Listen, it returns once the server has been shut down
+func (s *Server) Serve() {
+	s.lock.Lock()
+	server, listener := s.server, s.listener
+	adminServer, adminListener := s.adminServer, s.adminListener
+	s.lock.Unlock()
+
+	if listener == nil {
+		return
+	}
+
+	if adminServer != nil {
+		go s.serve(adminServer, adminListener, "admin")
+	}
+
+	s.serve(server, listener, "service")
+}
+
+// Addr returns the address the service is listening on, or nil if it is not listening
+func (s *Server) Addr() net.Addr {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.listener == nil {
+		return nil
+	}
+
+	return s.listener.Addr()
+}
+
+// AdminAddr returns the address the administrative endpoints are served on, or nil if they are not served
+// on a separate port
+func (s *Server) AdminAddr() net.Addr {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.adminListener == nil {
+		return nil
+	}
+
+	return s.adminListener.Addr()
+}
+
+// Shutdown gracefully shuts down the server, waiting for the requests being handled to complete until
+// the context is done. A server that has not been started yet will not start.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.lock.Lock()
+
+	if !s.shutdown {
+		s.shutdown = true
+		close(s.done)
+	}
+
+	server, listener := s.server, s.listener
+	adminServer, adminListener := s.adminServer, s.adminListener
+	s.lock.Unlock()
+
+	var err error
+
+	if adminServer != nil {
+		err = adminServer.Shutdown(ctx)
+		// the listener is not closed by Shutdown if it has not been served yet
+		_ = adminListener.Close()
+	}
+
+	if server != nil {
+		if serr := server.Shutdown(ctx); serr != nil {
+			err = serr
+		}
+
+		_ = listener.Close()
+	}
+
+	return err
+}
+
+// Err returns the error that stopped the server from serving, or nil if it has not failed
+func (s *Server) Err() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.err
+}
+
+func (s *Server) serve(server *http.Server, listener net.Listener, description string) {
+	var err error
+
+	if s.source.GetBool(config.ServiceTLSEnabledKey) {
+		err = server.ServeTLS(listener, s.source.GetString(config.ServiceTLSCertFileKey), s.source.GetString(config.ServiceTLSKeyFileKey))
+	} else {
+		err = server.Serve(listener)
+	}
+
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		s.fail(description, err)
+	}
+}
+
+// fail records the error that stopped the server and asks the run loop to stop the service
+func (s *Server) fail(description string, err error) {
+	serviceName := "Unspecified"
+
+	if s.source.IsSet(config.ServiceNameKey) {
+		serviceName = s.source.GetString(config.ServiceNameKey)
+	}
+
+	zap.S().Errorf("Could not start %s %s: %v\n", serviceName, description, err)
+
+	err = fmt.Errorf("could not start the %s: %w", description, err)
+
+	s.lock.Lock()
+
+	if s.err == nil {
+		s.err = err
+	}
+
+	s.lock.Unlock()
+
+	s.Send(Control{Command: Stop, Reason: "the " + description + " could not be started", Err: err})
 }
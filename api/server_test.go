@@ -0,0 +1,167 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+
+	"github.com/birchwood-langham/web-service-bootstrap/config"
+	"github.com/birchwood-langham/web-service-bootstrap/logger/logtest"
+)
+
+type failingWriter struct {
+	*httptest.ResponseRecorder
+}
+
+func (w failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("broken pipe")
+}
+
+func TestRespondWithJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		writer   http.ResponseWriter
+		code     int
+		payload  interface{}
+		wantBody string
+		wantLog  string
+	}{
+		{"Test respond with payload", httptest.NewRecorder(), http.StatusOK, map[string]int{"count": 1}, `{"count":1}`, ""},
+		{"Test respond with error", httptest.NewRecorder(), http.StatusBadRequest, map[string]string{"error": "bad"}, `{"error":"bad"}`, ""},
+		{"Test write failure is logged", failingWriter{httptest.NewRecorder()}, http.StatusOK, "hello", "", "Could not write response: broken pipe"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := logtest.Capture(t)
+
+			RespondWithJSON(tt.writer, tt.code, tt.payload)
+
+			var recorder *httptest.ResponseRecorder
+
+			switch w := tt.writer.(type) {
+			case *httptest.ResponseRecorder:
+				recorder = w
+			case failingWriter:
+				recorder = w.ResponseRecorder
+			}
+
+			if recorder.Code != tt.code {
+				t.Errorf("code = %d, want %d", recorder.Code, tt.code)
+			}
+
+			if got := recorder.Body.String(); got != tt.wantBody {
+				t.Errorf("body = %s, want %s", got, tt.wantBody)
+			}
+
+			if tt.wantLog != "" {
+				logs.AssertLogged(t, zapcore.ErrorLevel, tt.wantLog)
+			} else if logs.Len() != 0 {
+				t.Errorf("unexpected log entries:\n%s", logs)
+			}
+		})
+	}
+}
+
+func TestSend(t *testing.T) {
+	tests := []struct {
+		name     string
+		control  chan Control
+		shutdown bool
+		want     bool
+	}{
+		{"Test send", make(chan Control, 1), false, true},
+		{"Test send after shutdown", make(chan Control), true, false},
+		{"Test send without control channel", nil, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewWithSource(config.NewSource(), "localhost", 0, tt.control)
+
+			if tt.shutdown {
+				if err := s.Shutdown(context.Background()); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if got := s.Send(Control{Command: Stop, Reason: "test"}); got != tt.want {
+				t.Errorf("Send() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestControlHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		control  chan Control
+		command  Command
+		wantCode int
+	}{
+		{"Test reload", make(chan Control, 1), Reload, http.StatusAccepted},
+		{"Test drain", make(chan Control, 1), Drain, http.StatusAccepted},
+		{"Test not running", nil, Reload, http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewWithSource(config.NewSource(), "localhost", 0, tt.control)
+
+			w := httptest.NewRecorder()
+			s.ControlHandler(tt.command).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/"+tt.command.String(), nil))
+
+			if w.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantCode)
+			}
+
+			if tt.control == nil {
+				return
+			}
+
+			if c := <-tt.control; c.Command != tt.command || c.Reason != "POST /admin/"+tt.command.String() {
+				t.Errorf("sent %v (%s), want %v", c.Command, c.Reason, tt.command)
+			}
+		})
+	}
+}
+
+func TestNewMessageChannel(t *testing.T) {
+	messages := make(chan struct{}, 1)
+	s := New("localhost", 0, messages)
+
+	defer s.Shutdown(context.Background())
+
+	tests := []struct {
+		name    string
+		command Command
+		want    bool
+	}{
+		{"Test reload is discarded", Reload, false},
+		{"Test stop is forwarded", Stop, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !s.Send(Control{Command: tt.command}) {
+				t.Fatalf("Send(%v) = false", tt.command)
+			}
+
+			select {
+			case <-messages:
+				if !tt.want {
+					t.Errorf("%v was forwarded to the message channel", tt.command)
+				}
+			case <-time.After(50 * time.Millisecond):
+				if tt.want {
+					t.Errorf("%v was not forwarded to the message channel", tt.command)
+				}
+			}
+		})
+	}
+}
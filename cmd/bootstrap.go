@@ -0,0 +1,402 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"go.uber.org/zap"
+
+	"github.com/birchwood-langham/web-service-bootstrap/api"
+	"github.com/birchwood-langham/web-service-bootstrap/config"
+	"github.com/birchwood-langham/web-service-bootstrap/logger"
+	"github.com/birchwood-langham/web-service-bootstrap/schedule"
+	"github.com/birchwood-langham/web-service-bootstrap/service"
+	"github.com/birchwood-langham/web-service-bootstrap/version"
+)
+
+// Bootstrap runs an application, it owns the root command and its sub commands, the configuration source,
+// the logger and the server of the application, so that several applications can be run side by side in one
+// process, for example in integration tests. The package level functions, such as Execute and AddCommand, use
+// the default bootstrap, which is the only one that reads the global configuration source and makes its logger
+// the global logger.
+type Bootstrap struct {
+	root        *cobra.Command
+	application service.Application
+	source      *config.Source
+	global      bool
+
+	cfgFile    string
+	profiles   []string
+	configErr  error
+	boundFlags map[string]*pflag.Flag
+
+	workers    []registeredWorker
+	supervisor *service.Supervisor
+	scheduler  *schedule.Scheduler
+	// initDone is closed once the application has returned from Init or InitContext
+	initDone chan struct{}
+
+	lock   sync.Mutex
+	log    *logger.Logger
+	server *api.Server
+}
+
+type registeredWorker struct {
+	name   string
+	worker service.Worker
+	policy service.RestartPolicy
+}
+
+var defaultBootstrap = newBootstrap(config.Global(), true)
+
+// Default returns the bootstrap used by the package level functions
+func Default() *Bootstrap {
+	return defaultBootstrap
+}
+
+// NewBootstrap creates a bootstrap with its own root command, configuration source and logger, independent
+// of the default bootstrap. Its logger is not made the global logger, entries logged with zap.L() or
+// logger.Named are written by the logger of the default bootstrap, use Logger to write to the bootstrap's
+// own sinks, and Config to read its configuration.
+func NewBootstrap() *Bootstrap {
+	return newBootstrap(config.NewSource(), false)
+}
+
+func newBootstrap(source *config.Source, global bool) *Bootstrap {
+	b := &Bootstrap{
+		source:     source,
+		global:     global,
+		boundFlags: make(map[string]*pflag.Flag),
+		scheduler:  schedule.New(),
+	}
+
+	b.root = b.newRootCmd()
+
+	return b
+}
+
+// Run runs the command given on the command line for the application and returns its error rather than
+// exiting, so that the service can be embedded in a larger binary or started by a test. Cancelling the
+// context stops the service as SIGTERM does, Run returns once the service has been shut down and cleaned
+// up. The arguments can be set with RootCommand().SetArgs, by default they are os.Args[1:].
+func (b *Bootstrap) Run(ctx context.Context, app service.Application) error {
+	b.application = app
+
+	if b.global {
+		// the global viper instance is replaced by viper.Reset
+		b.source = config.Global()
+	}
+
+	b.root.Use = app.Properties().Usage
+	b.root.Short = app.Properties().ShortDescription
+	b.root.Long = app.Properties().LongDescription
+	b.root.Version = version.Get().String()
+
+	return b.root.ExecuteContext(ctx)
+}
+
+// RootCommand returns the root command of the bootstrap so that you can extend it
+func (b *Bootstrap) RootCommand() *cobra.Command {
+	return b.root
+}
+
+// AddCommand adds additional commands to the root command of the bootstrap
+func (b *Bootstrap) AddCommand(commands ...*cobra.Command) {
+	b.root.AddCommand(commands...)
+}
+
+// AddWorker adds a background worker that is started once the server is listening, restarted according to
+// the policy when it returns, reported by the health endpoint and stopped when the service is shut down,
+// before the application is cleaned up
+func (b *Bootstrap) AddWorker(name string, worker service.Worker, policy service.RestartPolicy) {
+	b.workers = append(b.workers, registeredWorker{name: name, worker: worker, policy: policy})
+}
+
+// AddJob adds a job run on a schedule, given as a cron expression such as "*/15 * * * *", a descriptor such
+// as @daily or an interval such as "@every 10m", once the server is listening. A run that is due while the
+// previous run of the job has not completed is skipped. The jobs are listed, and can be run, by the
+// administrative endpoints under /jobs.
+func (b *Bootstrap) AddJob(name string, spec string, fn schedule.Func, opts schedule.Options) error {
+	s, err := schedule.Parse(spec)
+
+	if err != nil {
+		return fmt.Errorf("could not add job %s: %w", name, err)
+	}
+
+	return b.scheduler.Add(name, s, fn, opts)
+}
+
+// jobLogger returns the logger the outcome of the jobs is logged to, their level can be set with
+// log.levels.scheduler
+func (b *Bootstrap) jobLogger() *zap.Logger {
+	if b.global {
+		return logger.Named("scheduler")
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.log == nil {
+		return zap.L().Named("scheduler")
+	}
+
+	return b.log.Named("scheduler")
+}
+
+// Config returns the configuration source of the bootstrap
+func (b *Bootstrap) Config() *config.Source {
+	return b.source
+}
+
+// Server returns the server of the running service, or nil if the service is not running
+func (b *Bootstrap) Server() *api.Server {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.server
+}
+
+// Logger returns the logger built from the configuration of the bootstrap, before the configuration has
+// been read, and for the default bootstrap, it returns the global logger
+func (b *Bootstrap) Logger() *zap.Logger {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.global || b.log == nil {
+		return zap.L()
+	}
+
+	return b.log.Logger
+}
+
+func (b *Bootstrap) sugar() *zap.SugaredLogger {
+	return b.Logger().Sugar()
+}
+
+// logLevels returns the levels of the logger of the bootstrap, which are changed by the administrative
+// endpoints and the signals, the levels of the default bootstrap are the levels of the global logger
+func (b *Bootstrap) logLevels() *logger.Levels {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.global || b.log == nil {
+		return logger.DefaultLevels()
+	}
+
+	return b.log.Levels()
+}
+
+func (b *Bootstrap) setServer(server *api.Server) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.server = server
+}
+
+// initConfig reads in config file, profile files, config fragments and ENV variables if set.
+func (b *Bootstrap) initConfig() {
+	layers := config.Layers{
+		File:        b.cfgFile,
+		Name:        config.DefaultConfigName,
+		Profiles:    config.Profiles(b.profiles...),
+		FragmentDir: config.DefaultFragmentDir,
+	}
+
+	if b.cfgFile == "" {
+		// Find home directory.
+		home, err := homedir.Dir()
+		if err != nil {
+			b.configErr = err
+			return
+		}
+
+		// Search config in home directory with name "application" (without extension).
+		layers.Paths = []string{".", "./conf", home}
+	}
+
+	// If a config file is found, read it in along with the profile files and fragments.
+	b.configErr = b.source.Load(layers)
+}
+
+// initialize reads the configuration and sets up the logger before a command that runs the service, the
+// flags of the command are bound first so that the log level flag applies to the logger
+func (b *Bootstrap) initialize(cmd *cobra.Command, args []string) {
+	b.initConfig()
+	b.bindFlags(cmd)
+
+	if b.configErr != nil {
+		b.sugar().Errorf("Could not read in viper config: %v", b.configErr)
+		return
+	}
+
+	b.setupLogger()
+
+	b.sugar().Infof("Version: %s", version.Get())
+	b.sugar().Infof("Using config file: %s", b.source.ConfigFileUsed())
+
+	if p := config.Profiles(b.profiles...); len(p) > 0 {
+		b.sugar().Infof("Active profiles: %s", strings.Join(p, ", "))
+	}
+
+	b.logConfigSources()
+}
+
+// initConfigOnly reads the configuration without setting up the logger, for commands that write to stdout
+func (b *Bootstrap) initConfigOnly(cmd *cobra.Command, args []string) {
+	b.initConfig()
+}
+
+// reloadConfig reads the configuration again, reconfigures the logger and runs the OnConfigChange hook of
+// the application, if the configuration cannot be read the service continues with its current configuration
+func (b *Bootstrap) reloadConfig() {
+	b.initConfig()
+
+	if b.configErr != nil {
+		b.sugar().Errorf("Could not reload config: %v", b.configErr)
+		return
+	}
+
+	b.setupLogger()
+
+	b.sugar().Infof("Reloaded config file: %s", b.source.ConfigFileUsed())
+	b.logConfigSources()
+
+	// the error has been logged, the service continues with the reloaded configuration
+	_ = b.configChanged(context.Background())
+}
+
+func (b *Bootstrap) logConfigSources() {
+	sources := b.source.Sources()
+
+	for _, f := range sources.Files() {
+		b.sugar().Debugf("Merged config file: %s", f)
+	}
+
+	for _, k := range sources.Keys() {
+		source := sources.Source(k)
+
+		if flag, ok := b.flagSource(k); ok {
+			source = flag
+		}
+
+		b.sugar().Debugf("Config %s = %v supplied by %s", k, b.source.Redact(k, b.source.Get(k)), source)
+	}
+
+	for k := range b.boundFlags {
+		if flag, ok := b.flagSource(k); ok && sources.Source(k) == "" {
+			b.sugar().Debugf("Config %s = %v supplied by %s", k, b.source.Redact(k, b.source.Get(k)), flag)
+		}
+	}
+}
+
+// setupLogger builds the logger of the bootstrap from the application configuration, the default bootstrap
+// makes it the global logger. If the configuration is invalid when the application starts, the logger falls
+// back to writing to stdout, if it is invalid when it is reloaded the current logger continues to be used.
+func (b *Bootstrap) setupLogger() {
+	if b.global {
+		b.setupGlobalLogger()
+		return
+	}
+
+	l, err := logger.NewFromSource(b.source)
+
+	b.lock.Lock()
+	previous := b.log
+
+	switch {
+	case err == nil:
+		b.log = l
+	case previous == nil:
+		b.log, _ = logger.NewBuilder().Levels(logger.NewLevels()).Sinks(logger.SinkConfig{Type: logger.SinkStdout}).Build()
+	}
+
+	b.lock.Unlock()
+
+	if err != nil {
+		b.sugar().Errorf("Could not configure logging: %v", err)
+		return
+	}
+
+	if previous != nil {
+		_ = previous.Close()
+	}
+}
+
+func (b *Bootstrap) setupGlobalLogger() {
+	err := logger.Configure()
+
+	if err == nil {
+		return
+	}
+
+	if _, cerr := logger.ZapCore(); cerr != nil {
+		l, _ := logger.NewBuilder().Sinks(logger.SinkConfig{Type: logger.SinkStdout}).Build()
+		logger.ReplaceGlobals(l)
+	}
+
+	zap.S().Errorf("Could not configure logging: %v", err)
+}
+
+// closeLogger flushes and closes the logger of the bootstrap, entries logged afterwards are discarded
+func (b *Bootstrap) closeLogger() {
+	if b.global {
+		_ = logger.Close()
+		return
+	}
+
+	b.lock.Lock()
+	l := b.log
+	b.log = nil
+	b.lock.Unlock()
+
+	if l != nil {
+		_ = l.Close()
+	}
+}
+
+func (b *Bootstrap) checkConfiguration(configs ...string) {
+	for _, c := range configs {
+		if !b.source.IsSet(c) {
+			b.sugar().Warnf("could not find configuration for: %s, using default values", c)
+		}
+	}
+}
+
+func (b *Bootstrap) newRootCmd() *cobra.Command {
+	// the root command runs the service when no command is given, as an alias of the serve command,
+	// arguments that are not a known command are rejected rather than starting the service
+	root := &cobra.Command{
+		Args: func(cmd *cobra.Command, args []string) error {
+			return usageError(cobra.NoArgs(cmd, args))
+		},
+		PersistentPreRun: b.initialize,
+		PreRunE:          b.validateServe,
+		RunE:             b.runService,
+		SilenceErrors:    true,
+	}
+
+	// Here you will define your flags and configuration settings.
+	// Cobra supports persistent flags, which, if defined here,
+	// will be global for your application.
+	root.PersistentFlags().StringVar(&b.cfgFile, "config", "", "config file (default will search for $PWD/application.yaml then $HOME/application.yaml)")
+	root.PersistentFlags().StringSliceVar(&b.profiles, "profile", nil, "comma separated list of configuration profiles to merge over the config file (default $"+config.ProfileEnv+")")
+	addServeFlags(root.Flags())
+	root.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return usageError(err)
+	})
+
+	root.AddCommand(
+		b.newServeCmd(),
+		b.newConfigCmd(),
+		b.newHealthcheckCmd(),
+		b.newRoutesCmd(),
+		b.newVersionCmd(),
+	)
+
+	return root
+}
@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	"github.com/birchwood-langham/web-service-bootstrap/config"
+)
+
+// newConfigCmd creates the command grouping the commands used to inspect the effective configuration
+func (b *Bootstrap) newConfigCmd() *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the application configuration",
+		Long:  `Inspect, validate and initialize the configuration used by the application`,
+		// the config commands write to stdout, so the logger is not set up
+		PersistentPreRun: b.initConfigOnly,
+	}
+
+	configCmd.AddCommand(b.newConfigShowCmd(), b.newConfigValidateCmd(), b.newConfigKeysCmd(), b.newConfigInitCmd())
+
+	return configCmd
+}
+
+// newConfigShowCmd creates the command showing the effective configuration
+func (b *Bootstrap) newConfigShowCmd() *cobra.Command {
+	var showOutput string
+	var showSources bool
+
+	configShowCmd := &cobra.Command{
+		Use:          "show",
+		Short:        "Show the effective configuration",
+		Long:         `Show the effective configuration after merging the config file, profiles, fragments and environment variables, with secrets redacted`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if b.configErr != nil {
+				return b.configErr
+			}
+
+			if showSources {
+				return b.source.Sources().WriteReport(cmd.OutOrStdout())
+			}
+
+			return writeOutput(cmd.OutOrStdout(), showOutput, b.source.Redacted())
+		},
+	}
+
+	configShowCmd.Flags().StringVarP(&showOutput, "output", "o", "yaml", "output format, yaml or json")
+	configShowCmd.Flags().BoolVar(&showSources, "sources", false, "show the file or environment variable that supplied each value instead of the values")
+
+	return configShowCmd
+}
+
+// newConfigValidateCmd creates the command validating the configuration
+func (b *Bootstrap) newConfigValidateCmd() *cobra.Command {
+	var strictValidation bool
+
+	configValidateCmd := &cobra.Command{
+		Use:          "validate",
+		Short:        "Validate the configuration",
+		Long:         `Validate the configuration against the known configuration keys, exiting with a non-zero status if it is invalid`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if b.configErr != nil {
+				return b.configErr
+			}
+
+			if unknown := b.source.UnknownKeys(); len(unknown) > 0 {
+				if strictValidation {
+					return fmt.Errorf("unknown configuration keys: %s", strings.Join(unknown, ", "))
+				}
+
+				fmt.Fprintf(cmd.ErrOrStderr(), "Warning: unknown configuration keys: %s\n", strings.Join(unknown, ", "))
+			}
+
+			if err := b.source.Validate(); err != nil {
+				return err
+			}
+
+			fmt.Fprintln(cmd.OutOrStdout(), "Configuration is valid")
+
+			return nil
+		},
+	}
+
+	configValidateCmd.Flags().BoolVar(&strictValidation, "strict", false, "treat unknown configuration keys as errors")
+
+	return configValidateCmd
+}
+
+// newConfigKeysCmd creates the command listing the known configuration keys
+func (b *Bootstrap) newConfigKeysCmd() *cobra.Command {
+	var keysOutput string
+
+	configKeysCmd := &cobra.Command{
+		Use:          "keys",
+		Short:        "List the known configuration keys",
+		Long:         `List the known configuration keys with their default values, environment variables and descriptions`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			type key struct {
+				Name        string      `json:"name" yaml:"name"`
+				Default     interface{} `json:"default" yaml:"default"`
+				Env         string      `json:"env" yaml:"env"`
+				Description string      `json:"description" yaml:"description"`
+			}
+
+			var keys []key
+
+			for _, k := range config.Keys() {
+				keys = append(keys, key{k.Name, k.Default, config.EnvName(k.Name), k.Description})
+			}
+
+			if keysOutput != "table" {
+				return writeOutput(cmd.OutOrStdout(), keysOutput, keys)
+			}
+
+			tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "KEY\tDEFAULT\tENV\tDESCRIPTION")
+
+			for _, k := range keys {
+				fmt.Fprintf(tw, "%s\t%v\t%s\t%s\n", k.Name, k.Default, k.Env, k.Description)
+			}
+
+			return tw.Flush()
+		},
+	}
+
+	configKeysCmd.Flags().StringVarP(&keysOutput, "output", "o", "table", "output format, table, yaml or json")
+
+	return configKeysCmd
+}
+
+// newConfigInitCmd creates the command writing a default configuration file
+func (b *Bootstrap) newConfigInitCmd() *cobra.Command {
+	var forceInit bool
+
+	configInitCmd := &cobra.Command{
+		Use:          "init [file]",
+		Short:        "Write a default configuration file",
+		Long:         `Write a commented configuration file containing the default value of every known configuration key, to application.yaml unless another file is given`,
+		Args:         cobra.MaximumNArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			file := config.DefaultConfigName + ".yaml"
+
+			if len(args) > 0 {
+				file = args[0]
+			}
+
+			if file == "-" {
+				return config.WriteDefaults(cmd.OutOrStdout())
+			}
+
+			flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+
+			if forceInit {
+				flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+			}
+
+			f, err := os.OpenFile(file, flags, 0644)
+
+			if err != nil {
+				if os.IsExist(err) {
+					return fmt.Errorf("%s already exists, use --force to overwrite it", file)
+				}
+
+				return err
+			}
+
+			if err := config.WriteDefaults(f); err != nil {
+				_ = f.Close()
+				return err
+			}
+
+			if err := f.Close(); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Wrote default configuration to %s\n", file)
+
+			return nil
+		},
+	}
+
+	configInitCmd.Flags().BoolVarP(&forceInit, "force", "f", false, "overwrite the file if it already exists")
+
+	return configInitCmd
+}
+
+func writeOutput(w io.Writer, format string, v interface{}) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(v)
+	case "yaml":
+		b, err := yaml.Marshal(v)
+
+		if err != nil {
+			return err
+		}
+
+		_, err = w.Write(b)
+
+		return err
+	default:
+		return fmt.Errorf("unsupported output format: %s", format)
+	}
+}
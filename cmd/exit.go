@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/birchwood-langham/web-service-bootstrap/api"
+	"github.com/birchwood-langham/web-service-bootstrap/config"
+)
+
+const (
+	// ExitFailure is the exit status of an application that failed to start or stopped with an error
+	ExitFailure = 1
+	// ExitUsage is the exit status of an application given invalid arguments, flags or configuration
+	ExitUsage = 2
+)
+
+// ExitError is an error carrying the exit status Execute exits with, an application can return it
+// from Init to choose the exit status
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit status %d", e.Code)
+	}
+
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the exit status for an error returned by Run, 0 if there was no error
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var exitErr *ExitError
+
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+
+	var validationErr *config.ValidationError
+
+	if errors.As(err, &validationErr) {
+		return ExitUsage
+	}
+
+	return ExitFailure
+}
+
+// usageError marks an error in the arguments or flags of a command so that Execute exits with ExitUsage
+func usageError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &ExitError{Code: ExitUsage, Err: err}
+}
+
+// withStatus applies the exit status requested by the message that stopped the service to the error of the
+// shutdown, a stop message with a non-zero status makes Execute exit with it even if there was no error
+func withStatus(stop api.Control, err error) error {
+	if stop.Status == 0 {
+		return err
+	}
+
+	if err == nil {
+		err = errors.New(stop.Reason)
+	}
+
+	return &ExitError{Code: stop.Status, Err: err}
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/birchwood-langham/web-service-bootstrap/api"
+	"github.com/birchwood-langham/web-service-bootstrap/config"
+)
+
+// newHealthcheckCmd creates the command probing the health endpoint of a running instance, for container
+// health checks in images that do not include a HTTP client such as curl
+func (b *Bootstrap) newHealthcheckCmd() *cobra.Command {
+	var healthcheckAdmin bool
+	var healthcheckTimeout time.Duration
+
+	healthcheckCmd := &cobra.Command{
+		Use:   "healthcheck",
+		Short: "Check the health of the running service",
+		Long: `Call the health endpoint of the service running with the same configuration, exiting with status 0 if it is healthy and 1 otherwise.
+The health endpoint is not served by default, configure its path with service.health-path or enable the
+administrative endpoints with admin.enabled, otherwise the command always reports the service as unhealthy.
+It can be used as the health check of a container image that does not include curl:
+
+  HEALTHCHECK CMD ["/app/service", "healthcheck"]`,
+		Args: cobra.NoArgs,
+		// the result is written to stdout, so the logger is not set up
+		PersistentPreRun: b.initConfigOnly,
+		SilenceUsage:     true,
+		SilenceErrors:    true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if b.configErr != nil {
+				return fmt.Errorf("unhealthy: %v", b.configErr)
+			}
+
+			url, err := healthURL(b.source, healthcheckAdmin)
+
+			if err != nil {
+				return fmt.Errorf("unhealthy: %v", err)
+			}
+
+			client := &http.Client{
+				Timeout: healthcheckTimeout,
+				Transport: &http.Transport{
+					// the probe connects to the local instance, whose certificate is issued for its public name
+					TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+				},
+			}
+
+			resp, err := client.Get(url)
+
+			if err != nil {
+				return fmt.Errorf("unhealthy: %v", err)
+			}
+
+			defer resp.Body.Close()
+
+			var status api.HealthStatus
+
+			_ = json.NewDecoder(resp.Body).Decode(&status)
+
+			if resp.StatusCode != http.StatusOK {
+				return fmt.Errorf("unhealthy: %s%s", resp.Status, failedChecks(status))
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "healthy: %s\n", url)
+
+			return nil
+		},
+	}
+
+	healthcheckCmd.Flags().BoolVar(&healthcheckAdmin, "admin", false, "probe the health endpoint under the admin path, on the admin port if one has been configured, it is probed there when its path on the service port has not been configured")
+	healthcheckCmd.Flags().DurationVar(&healthcheckTimeout, "timeout", 5*time.Second, "how long to wait for the service to respond")
+
+	return healthcheckCmd
+}
+
+// healthURL returns the URL of the health endpoint on the service port, or under the admin path if it is not
+// served on the service port or the admin endpoint has been asked for
+func healthURL(source *config.Source, admin bool) (string, error) {
+	path := source.GetString(config.ServiceHealthPathKey)
+
+	if path == "" && !admin {
+		if !source.GetBool(config.AdminEnabledKey) {
+			return "", fmt.Errorf("the health endpoint is not served, configure its path with %s or enable the administrative endpoints with %s",
+				config.ServiceHealthPathKey, config.AdminEnabledKey)
+		}
+
+		admin = true
+	}
+
+	port := source.GetInt(config.ServicePortKey)
+
+	if !source.IsSet(config.ServicePortKey) {
+		port = 9900
+	}
+
+	if admin {
+		if !source.GetBool(config.AdminEnabledKey) {
+			return "", fmt.Errorf("the administrative endpoints have not been enabled by %s", config.AdminEnabledKey)
+		}
+
+		path = api.AdminPathPrefix + config.DefaultHealthPath
+
+		if adminPort := source.GetInt(config.AdminPortKey); adminPort != 0 {
+			port = adminPort
+		}
+	}
+
+	scheme := "http"
+
+	if source.GetBool(config.ServiceTLSEnabledKey) {
+		scheme = "https"
+	}
+
+	return fmt.Sprintf("%s://%s%s", scheme, net.JoinHostPort(probeHost(source.GetString(config.ServiceHostKey)), strconv.Itoa(port)), path), nil
+}
+
+// probeHost returns the host to connect to, a service listening on all interfaces is probed on localhost
+func probeHost(host string) string {
+	switch host {
+	case "", "0.0.0.0", "::", "[::]":
+		return "localhost"
+	default:
+		return host
+	}
+}
+
+// failedChecks describes the checks that failed, e.g. ": db: connection refused"
+func failedChecks(status api.HealthStatus) string {
+	var failed []string
+
+	for name, check := range status.Checks {
+		if check.Status != api.HealthUp {
+			failed = append(failed, fmt.Sprintf("%s: %s", name, check.Error))
+		}
+	}
+
+	if len(failed) == 0 {
+		return ""
+	}
+
+	sort.Strings(failed)
+
+	return ": " + strings.Join(failed, ", ")
+}
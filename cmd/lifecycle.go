@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/birchwood-langham/web-service-bootstrap/config"
+	"github.com/birchwood-langham/web-service-bootstrap/service"
+)
+
+// runHook runs a lifecycle hook of the application with the configured timeout, a hook that does not
+// return before the timeout is abandoned and reported as failed
+func (b *Bootstrap) runHook(parent context.Context, name string, hook func(ctx context.Context) error) error {
+	ctx, cancel := b.withTimeout(parent, config.ServiceHookTimeoutKey, config.DefaultHookTimeout)
+	defer cancel()
+
+	err := runUntilDone(ctx, hook)
+
+	if err != nil {
+		err = fmt.Errorf("%s: %w", name, err)
+		b.sugar().Errorf("Lifecycle hook %v", err)
+	}
+
+	return err
+}
+
+// runUntilDone runs fn until it returns or the context is done, in which case fn is abandoned and the error
+// of the context is returned, so that a call that does not respect its context cannot block the service
+func runUntilDone(ctx context.Context, fn func(ctx context.Context) error) error {
+	done := make(chan error, 1)
+
+	go func() {
+		done <- fn(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// timeout returns the duration configured by the key, or the default if it has not been configured
+func (b *Bootstrap) timeout(key string, defaultTimeout time.Duration) time.Duration {
+	if b.source.IsSet(key) {
+		return b.source.GetDuration(key)
+	}
+
+	return defaultTimeout
+}
+
+// withTimeout returns a context that is done once the duration configured by the key has passed, a duration
+// of 0 does not set a deadline
+func (b *Bootstrap) withTimeout(parent context.Context, key string, defaultTimeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout := b.timeout(key, defaultTimeout); timeout > 0 {
+		return context.WithTimeout(parent, timeout)
+	}
+
+	return context.WithCancel(parent)
+}
+
+// initApplication initializes the application with InitContext if it implements it, or Init otherwise. Only
+// InitContext is given the startup deadline, Init is called without a deadline as it does not accept a context.
+func (b *Bootstrap) initApplication(ctx context.Context) error {
+	done := make(chan struct{})
+	b.initDone = done
+
+	var err error
+
+	if app, ok := b.application.(service.ContextInitializer); ok {
+		err = runUntilDone(ctx, func(ctx context.Context) error {
+			defer close(done)
+			return app.InitContext(ctx)
+		})
+	} else {
+		err = b.application.Init()
+		close(done)
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not initialize the application: %w", err)
+	}
+
+	return nil
+}
+
+// cleanupApplication cleans up the application with CleanupContext if it implements it, or Cleanup otherwise.
+// An InitContext abandoned when the startup deadline passed is waited for, the application is not cleaned up
+// while it is still being initialized.
+func (b *Bootstrap) cleanupApplication(ctx context.Context) error {
+	if b.initDone != nil {
+		select {
+		case <-b.initDone:
+		case <-ctx.Done():
+			return fmt.Errorf("could not clean up the application, it is still being initialized: %w", ctx.Err())
+		}
+	}
+
+	err := runUntilDone(ctx, func(ctx context.Context) error {
+		if app, ok := b.application.(service.ContextCleaner); ok {
+			return app.CleanupContext(ctx)
+		}
+
+		return b.application.Cleanup()
+	})
+
+	if err != nil {
+		return fmt.Errorf("could not clean up the application: %w", err)
+	}
+
+	return nil
+}
+
+// beforeStart runs the BeforeStart hook of the application, if it implements it
+func (b *Bootstrap) beforeStart(ctx context.Context) error {
+	h, ok := b.application.(service.BeforeStarter)
+
+	if !ok {
+		return nil
+	}
+
+	return b.runHook(ctx, "BeforeStart", h.BeforeStart)
+}
+
+// afterStart runs the AfterStart hook of the application with the address the service is listening on
+func (b *Bootstrap) afterStart(ctx context.Context, addr net.Addr) error {
+	h, ok := b.application.(service.AfterStarter)
+
+	if !ok {
+		return nil
+	}
+
+	return b.runHook(ctx, "AfterStart", func(ctx context.Context) error {
+		return h.AfterStart(ctx, addr)
+	})
+}
+
+// beforeShutdown runs the BeforeShutdown hook of the application, if it implements it
+func (b *Bootstrap) beforeShutdown(ctx context.Context) error {
+	h, ok := b.application.(service.BeforeShutdowner)
+
+	if !ok {
+		return nil
+	}
+
+	return b.runHook(ctx, "BeforeShutdown", h.BeforeShutdown)
+}
+
+// configChanged runs the OnConfigChange hook of the application with the reloaded configuration
+func (b *Bootstrap) configChanged(ctx context.Context) error {
+	h, ok := b.application.(service.ConfigChangeHandler)
+
+	if !ok {
+		return nil
+	}
+
+	return b.runHook(ctx, "OnConfigChange", func(ctx context.Context) error {
+		return h.OnConfigChange(ctx, b.source)
+	})
+}
@@ -0,0 +1,360 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/birchwood-langham/web-service-bootstrap/service"
+)
+
+type hookApp struct {
+	testApp
+	beforeStartErr error
+	afterStartErr  error
+	block          bool
+	started        chan struct{}
+
+	lock  sync.Mutex
+	calls []string
+	addr  net.Addr
+}
+
+func (a *hookApp) record(call string) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	a.calls = append(a.calls, call)
+}
+
+func (a *hookApp) recorded() ([]string, net.Addr) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	return a.calls, a.addr
+}
+
+func (a *hookApp) BeforeStart(ctx context.Context) error {
+	a.record("BeforeStart")
+
+	if a.block {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	return a.beforeStartErr
+}
+
+func (a *hookApp) AfterStart(ctx context.Context, addr net.Addr) error {
+	a.record("AfterStart")
+
+	a.lock.Lock()
+	a.addr = addr
+	a.lock.Unlock()
+
+	close(a.started)
+
+	return a.afterStartErr
+}
+
+func (a *hookApp) BeforeShutdown(ctx context.Context) error {
+	a.record("BeforeShutdown")
+	return nil
+}
+
+func TestLifecycleHooks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cmd")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "application.yaml")
+
+	if err := ioutil.WriteFile(file, []byte("service:\n  hook-timeout: 50ms\nlog:\n  sinks:\n    - type: none\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	port := freePort(t)
+
+	tests := []struct {
+		name      string
+		app       *hookApp
+		wantCalls []string
+		wantErr   error
+		serving   bool
+	}{
+		{"Test hooks run in order", &hookApp{}, []string{"BeforeStart", "AfterStart", "BeforeShutdown"}, nil, true},
+		{"Test before start fails", &hookApp{beforeStartErr: errors.New("no cache")}, []string{"BeforeStart"}, nil, false},
+		{"Test before start times out", &hookApp{block: true}, []string{"BeforeStart"}, context.DeadlineExceeded, false},
+		{"Test after start fails", &hookApp{afterStartErr: errors.New("no registry")}, []string{"BeforeStart", "AfterStart", "BeforeShutdown"}, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.app.started = make(chan struct{})
+
+			b := NewBootstrap()
+			b.RootCommand().SetArgs([]string{"serve", "--config", file, "--port", strconv.Itoa(port)})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			done := make(chan error, 1)
+
+			go func() {
+				done <- b.Run(ctx, tt.app)
+			}()
+
+			if tt.serving {
+				select {
+				case <-tt.app.started:
+				case <-time.After(5 * time.Second):
+					t.Fatal("AfterStart was not called")
+				}
+
+				cancel()
+			}
+
+			select {
+			case err := <-done:
+				switch {
+				case tt.serving && err != nil:
+					t.Errorf("Run() error = %v", err)
+				case !tt.serving && err == nil:
+					t.Error("Run() error = nil, want the error of the hook")
+				case tt.wantErr != nil && !errors.Is(err, tt.wantErr):
+					t.Errorf("Run() error = %v, want %v", err, tt.wantErr)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("Run() did not return")
+			}
+
+			calls, addr := tt.app.recorded()
+
+			if !reflect.DeepEqual(calls, tt.wantCalls) {
+				t.Errorf("hooks called = %v, want %v", calls, tt.wantCalls)
+			}
+
+			if !tt.app.cleaned {
+				t.Error("the application was not cleaned up")
+			}
+
+			if addr != nil && addr.(*net.TCPAddr).Port != port {
+				t.Errorf("AfterStart addr = %v, want port %d", addr, port)
+			}
+		})
+	}
+}
+
+type contextApp struct {
+	testApp
+	blockInit    bool
+	blockCleanup bool
+
+	lock          sync.Mutex
+	cleanupCalled bool
+	cleanupErr    error
+}
+
+// cleanedUp returns whether CleanupContext has been called, and the error of its context when it was called
+func (a *contextApp) cleanedUp() (bool, error) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	return a.cleanupCalled, a.cleanupErr
+}
+
+func (a *contextApp) InitContext(ctx context.Context) error {
+	if a.blockInit {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	return nil
+}
+
+func (a *contextApp) CleanupContext(ctx context.Context) error {
+	a.lock.Lock()
+	a.cleanupCalled = true
+	a.cleanupErr = ctx.Err()
+	a.lock.Unlock()
+
+	if a.blockCleanup {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	return nil
+}
+
+func TestContextApplication(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cmd")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "application.yaml")
+	content := "service:\n  health-path: /health\n  startup-timeout: 100ms\n  shutdown-timeout: 100ms\nlog:\n  sinks:\n    - type: none\n"
+
+	if err := ioutil.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	port := freePort(t)
+
+	tests := []struct {
+		name        string
+		app         *contextApp
+		serving     bool
+		wantCleaned bool
+	}{
+		{"Test init times out", &contextApp{blockInit: true}, false, true},
+		{"Test cleanup times out", &contextApp{blockCleanup: true}, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBootstrap()
+			b.RootCommand().SetArgs([]string{"serve", "--config", file, "--port", strconv.Itoa(port)})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			done := make(chan error, 1)
+
+			go func() {
+				done <- b.Run(ctx, tt.app)
+			}()
+
+			if tt.serving {
+				waitForHealth(t, "http://localhost:"+strconv.Itoa(port)+"/health")
+				cancel()
+			}
+
+			select {
+			case err := <-done:
+				if !errors.Is(err, context.DeadlineExceeded) {
+					t.Errorf("Run() error = %v, want %v", err, context.DeadlineExceeded)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("Run() did not return")
+			}
+
+			cleaned, ctxErr := tt.app.cleanedUp()
+
+			if cleaned != tt.wantCleaned {
+				t.Errorf("cleaned up = %v, want %v", cleaned, tt.wantCleaned)
+			}
+
+			if ctxErr != nil {
+				t.Errorf("cleanup context error = %v, want the cleanup to be given a live context", ctxErr)
+			}
+		})
+	}
+}
+
+// slowApp is initialized by Init, which does not accept a context and is not given the startup deadline
+type slowApp struct {
+	testApp
+	delay time.Duration
+}
+
+func (a *slowApp) Init() error {
+	time.Sleep(a.delay)
+	return nil
+}
+
+// stubbornApp is initialized by InitContext, which does not return when its context is done
+type stubbornApp struct {
+	testApp
+	release chan struct{}
+}
+
+func (a *stubbornApp) InitContext(ctx context.Context) error {
+	<-a.release
+	return nil
+}
+
+func TestInitDeadline(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cmd")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "application.yaml")
+	content := "service:\n  health-path: /health\n  startup-timeout: 50ms\n  shutdown-timeout: 100ms\nlog:\n  sinks:\n    - type: none\n"
+
+	if err := ioutil.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	port := freePort(t)
+	slow := &slowApp{delay: 200 * time.Millisecond}
+	stubborn := &stubbornApp{release: make(chan struct{})}
+
+	defer close(stubborn.release)
+
+	tests := []struct {
+		name        string
+		app         service.Application
+		cleaned     func() bool
+		serving     bool
+		wantErr     string
+		wantCleaned bool
+	}{
+		{"Test Init is not given the startup deadline", slow, func() bool { return slow.cleaned }, true, "", true},
+		{"Test abandoned InitContext is not cleaned up", stubborn, func() bool { return stubborn.cleaned }, false, "it is still being initialized", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBootstrap()
+			b.RootCommand().SetArgs([]string{"serve", "--config", file, "--port", strconv.Itoa(port)})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			done := make(chan error, 1)
+
+			go func() {
+				done <- b.Run(ctx, tt.app)
+			}()
+
+			if tt.serving {
+				waitForHealth(t, "http://localhost:"+strconv.Itoa(port)+"/health")
+				cancel()
+			}
+
+			select {
+			case err := <-done:
+				if (err != nil) != (tt.wantErr != "") || err != nil && !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Run() error = %v, want %q", err, tt.wantErr)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("Run() did not return")
+			}
+
+			if tt.cleaned() != tt.wantCleaned {
+				t.Errorf("cleaned up = %v, want %v", tt.cleaned(), tt.wantCleaned)
+			}
+		})
+	}
+}
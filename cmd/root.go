@@ -15,173 +15,130 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 	"os"
-	"os/signal"
-	"syscall"
 
 	"go.uber.org/zap"
 
 	"github.com/birchwood-langham/web-service-bootstrap/api"
 	"github.com/birchwood-langham/web-service-bootstrap/config"
-	"github.com/birchwood-langham/web-service-bootstrap/logger"
+	"github.com/birchwood-langham/web-service-bootstrap/schedule"
 	"github.com/birchwood-langham/web-service-bootstrap/service"
+	"github.com/birchwood-langham/web-service-bootstrap/version"
 
-	"github.com/mitchellh/go-homedir"
+	"github.com/gorilla/mux"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
-var cfgFile string
-var application service.Application
-
-var rootCmd = &cobra.Command{
-	Run: startService,
-}
-
 // MaxPort returns the maximum port number available to run your service on
 func MaxPort() int {
 	return int(^uint16(0))
 }
 
-func startService(cmd *cobra.Command, args []string) {
-	if err := application.Init(); err != nil {
-		zap.S().Fatalf("Could not initialize the application -- %s", err)
-	}
-
-	signalChannel := make(chan os.Signal, 100)
-	signal.Notify(signalChannel, syscall.SIGINT, syscall.SIGTERM)
-
-	checkConfiguration(config.ServiceHostKey, config.ServicePortKey)
+// newServer creates a server with the routes provided by the bootstrap and the application
+func (b *Bootstrap) newServer(control chan api.Control, host string, port int, initializeRoutes func(*api.Server)) *api.Server {
+	server := api.NewWithSource(b.source, host, port, control)
 
-	serverHost := "localhost"
-
-	if viper.IsSet(config.ServiceHostKey) {
-		serverHost = viper.GetString(config.ServiceHostKey)
-	}
-
-	serverPort := 9900
-
-	if viper.IsSet(config.ServicePortKey) {
-		serverPort = viper.GetInt(config.ServicePortKey)
-	}
+	server.Initialize(func(s *api.Server) {
+		b.initializeAdminRoutes(s)
+		b.initializeJobRoutes(s)
+		initializeRoutes(s)
+		// registered after the routes of the application, so that they do not shadow its endpoints
+		initializeInfoRoute(s)
+		initializeHealthRoutes(s)
+	})
 
-	maxPort := MaxPort()
-
-	if serverPort == 0 || serverPort > maxPort {
-		zap.S().Fatalf("Server port is out of range, port must be between %d and %d", 1, maxPort)
-	}
-
-	zap.S().Infof("Starting service on %s:%d", serverHost, serverPort)
-
-	serverMsgChannel := make(chan struct{}, viper.GetInt(config.ServiceCommandBufferKey))
-
-	go startServer(serverMsgChannel, serverHost, serverPort, application.InitializeRoutes)
-
-runLoop:
-	for {
-		select {
-		case incomingSignal := <-signalChannel:
-			zap.S().Infof("Caught signal %v: terminating\n", incomingSignal)
-
-			serverMsgChannel <- struct{}{}
-		case <-serverMsgChannel:
-			zap.S().Info("Stop request from API server has been received, stopping service")
-			if err := application.Cleanup(); err != nil {
-				zap.S().Errorf("Could not execute cleanup - %s", err)
-			}
-
-			break runLoop
-		}
-	}
+	return server
 }
 
-func startServer(messageChannel chan struct{}, host string, port int, initializeRoutes func(*api.Server)) {
-	server := api.New(host, port, messageChannel)
+// initializeInfoRoute registers the endpoint reporting the version of the service under the admin path, it is
+// only served on the service port if a path has been configured, as it reveals the build of the service
+func initializeInfoRoute(s *api.Server) {
+	s.Admin.Handle(config.DefaultInfoPath, version.Handler()).Methods(http.MethodGet)
 
-	server.Initialize(initializeRoutes)
-	server.Run()
-}
-
-func checkConfiguration(configs ...string) {
-	for _, c := range configs {
-		if !viper.IsSet(c) {
-			zap.S().Warnf("could not find configuration for: %s, using default values", c)
-		}
+	if path := s.Config().GetString(config.ServiceInfoPathKey); path != "" {
+		s.Router.Handle(path, version.Handler()).Methods(http.MethodGet)
 	}
 }
 
-// Execute adds all child commands to the root command and sets flags appropriately.
-// This is called by main.main(). It only needs to happen once to the rootCmd.
-func Execute(app service.Application) {
-	application = app
-
-	rootCmd.Use = app.Properties().Usage
-	rootCmd.Short = app.Properties().ShortDescription
-	rootCmd.Long = app.Properties().LongDescription
+// initializeHealthRoutes registers the endpoint reporting the health of the service under the admin path, it is
+// only served on the service port if a path has been configured
+func initializeHealthRoutes(s *api.Server) {
+	s.Admin.Handle(config.DefaultHealthPath, s.HealthHandler()).Methods(http.MethodGet)
 
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+	if path := s.Config().GetString(config.ServiceHealthPathKey); path != "" {
+		s.Router.Handle(path, s.HealthHandler()).Methods(http.MethodGet)
 	}
 }
 
-func init() {
-	cobra.OnInitialize(initConfig)
-	// Here you will define your flags and configuration settings.
-	// Cobra supports persistent flags, which, if defined here,
-	// will be global for your application.
-	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default will search for $PWD/application.yaml then $HOME/application.yaml)")
-}
+// initializeAdminRoutes registers the administrative endpoints provided by the bootstrap, the log level endpoints
+// change the levels of the logger of the bootstrap, which is rebuilt when the configuration is reloaded
+func (b *Bootstrap) initializeAdminRoutes(s *api.Server) {
+	s.Admin.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
 
-// initConfig reads in config file and ENV variables if set.
-func initConfig() {
-	//initLogger()
-
-	if cfgFile != "" {
-		// Use config file from the flag.
-		viper.SetConfigFile(cfgFile)
-	} else {
-		// Find home directory.
-		home, err := homedir.Dir()
-		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+		if err := version.WriteMetric(w); err != nil {
+			zap.S().Errorf("Could not write metrics: %v", err)
 		}
+	}).Methods(http.MethodGet)
+	s.Admin.Handle("/reload", s.ControlHandler(api.Reload)).Methods(http.MethodPost)
+	s.Admin.Handle("/drain", s.ControlHandler(api.Drain)).Methods(http.MethodPost)
+	s.Admin.HandleFunc("/log/level", func(w http.ResponseWriter, r *http.Request) {
+		b.logLevels().Handler().ServeHTTP(w, r)
+	}).Methods(http.MethodGet, http.MethodPut)
+	s.Admin.HandleFunc("/log/levels", func(w http.ResponseWriter, r *http.Request) {
+		b.logLevels().ComponentLevelsHandler().ServeHTTP(w, r)
+	}).Methods(http.MethodGet)
+	s.Admin.HandleFunc("/log/levels/{name}", func(w http.ResponseWriter, r *http.Request) {
+		b.logLevels().ComponentLevelHandler(mux.Vars(r)["name"]).ServeHTTP(w, r)
+	}).Methods(http.MethodGet, http.MethodPut, http.MethodDelete)
+}
 
-		// Search config in home directory with name "application" (without extension).
-		viper.AddConfigPath(".")
-		viper.AddConfigPath("./conf")
-		viper.AddConfigPath(home)
-		viper.SetConfigName("application")
-	}
-
-	viper.AutomaticEnv() // read in environment variables that match
-
-	// If a config file is found, read it in.
-	if err := viper.ReadInConfig(); err != nil {
-		zap.S().Errorf("Could not read in viper config: %v", err)
+// initializeJobRoutes registers the administrative endpoints listing the scheduled jobs and running them
+func (b *Bootstrap) initializeJobRoutes(s *api.Server) {
+	if b.scheduler.Len() == 0 {
 		return
 	}
 
-	setupLogger()
+	s.Admin.Handle("/jobs", b.scheduler.Handler()).Methods(http.MethodGet)
+	s.Admin.HandleFunc("/jobs/{name}/run", func(w http.ResponseWriter, r *http.Request) {
+		b.scheduler.TriggerHandler(mux.Vars(r)["name"]).ServeHTTP(w, r)
+	}).Methods(http.MethodPost)
+}
 
-	zap.S().Infof("Using config file: %s", viper.ConfigFileUsed())
+// Execute adds all child commands to the root command and sets flags appropriately.
+// This is called by main.main(). It only needs to happen once to the rootCmd. If the application
+// fails, the error is printed and the process exits with the status given by ExitCode.
+func Execute(app service.Application) {
+	if err := Run(context.Background(), app); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(ExitCode(err))
+	}
 }
 
-func setupLogger() {
-	l := logger.New(logger.ApplicationLogLevel(), logger.DefaultLumberjackLogger())
-	zap.ReplaceGlobals(l)
+// Run runs the application with the default bootstrap, see Bootstrap.Run
+func Run(ctx context.Context, app service.Application) error {
+	return defaultBootstrap.Run(ctx, app)
 }
 
 // GetRootCommand returns the service RootCommand so that you can extend it and add your own commands
 func GetRootCommand() *cobra.Command {
-	return rootCmd
+	return defaultBootstrap.RootCommand()
 }
 
 // AddCommand adds additional commands to the Root Command
 func AddCommand(commands ...*cobra.Command) {
-	for _, c := range commands {
-		rootCmd.AddCommand(c)
-	}
+	defaultBootstrap.AddCommand(commands...)
+}
+
+// AddWorker adds a background worker to the service run by Execute
+func AddWorker(name string, worker service.Worker, policy service.RestartPolicy) {
+	defaultBootstrap.AddWorker(name, worker, policy)
+}
+
+// AddJob adds a scheduled job to the service run by Execute, see Bootstrap.AddJob
+func AddJob(name string, spec string, fn schedule.Func, opts schedule.Options) error {
+	return defaultBootstrap.AddJob(name, spec, fn, opts)
 }
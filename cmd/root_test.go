@@ -0,0 +1,528 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"github.com/birchwood-langham/web-service-bootstrap/api"
+	"github.com/birchwood-langham/web-service-bootstrap/config"
+	"github.com/birchwood-langham/web-service-bootstrap/service"
+)
+
+type testApp struct {
+	initErr error
+	cleaned bool
+}
+
+func (a *testApp) Init() error { return a.initErr }
+
+func (a *testApp) InitializeRoutes(s *api.Server) {}
+
+func (a *testApp) Cleanup() error {
+	a.cleaned = true
+	return nil
+}
+
+func (a *testApp) Properties() service.Properties {
+	return service.NewProperties("test", "test service", "test service")
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"Test no error", nil, 0},
+		{"Test error", errors.New("failed"), ExitFailure},
+		{"Test exit error", &ExitError{Code: 3, Err: errors.New("failed")}, 3},
+		{"Test wrapped exit error", fmt.Errorf("init: %w", &ExitError{Code: 4}), 4},
+		{"Test usage error", usageError(errors.New("unknown flag")), ExitUsage},
+		{"Test invalid configuration", &config.ValidationError{Problems: []string{"service.port: 0 must be between 1 and 65535"}}, ExitUsage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Errorf("ExitCode() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cmd")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "application.yaml")
+
+	if err := ioutil.WriteFile(file, []byte("service:\n  health-path: /health\nlog:\n  sinks:\n    - type: none\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	port := freePort(t)
+
+	tests := []struct {
+		name        string
+		app         *testApp
+		args        []string
+		wantCode    int
+		serving     bool
+		wantCleaned bool
+	}{
+		{"Test cancelled", &testApp{}, []string{"serve", "--config", file, "--port", strconv.Itoa(port)}, 0, true, true},
+		{"Test init error", &testApp{initErr: errors.New("no database")}, []string{"--config", file, "--port", strconv.Itoa(port)}, ExitFailure, false, true},
+		{"Test invalid port", &testApp{}, []string{"--config", file, "--port", "70000"}, ExitUsage, false, false},
+		{"Test unknown command", &testApp{}, []string{"sevre", "--config", file}, ExitUsage, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBootstrap()
+			b.RootCommand().SetArgs(tt.args)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			done := make(chan error, 1)
+
+			go func() {
+				done <- b.Run(ctx, tt.app)
+			}()
+
+			if tt.serving {
+				waitForHealth(t, fmt.Sprintf("http://localhost:%d/health", port))
+				cancel()
+			}
+
+			select {
+			case err := <-done:
+				if got := ExitCode(err); got != tt.wantCode {
+					t.Errorf("Run() error = %v, exit code %d, want %d", err, got, tt.wantCode)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("Run() did not return")
+			}
+
+			if tt.app.cleaned != tt.wantCleaned {
+				t.Errorf("cleaned up = %v, want %v", tt.app.cleaned, tt.wantCleaned)
+			}
+		})
+	}
+}
+
+func freePort(t *testing.T) int {
+	l, err := net.Listen("tcp", "localhost:0")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer l.Close()
+
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+func waitForHealth(t *testing.T, url string) {
+	for i := 0; i < 50; i++ {
+		if resp, err := http.Get(url); err == nil {
+			resp.Body.Close()
+
+			if resp.StatusCode == http.StatusOK {
+				return
+			}
+		}
+
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	t.Fatalf("%s did not become healthy", url)
+}
+
+func TestRunSideBySide(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cmd")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	names := []string{"first", "second"}
+	bootstraps := make([]*Bootstrap, len(names))
+	ports := make([]int, len(names))
+	done := make(chan error, len(names))
+
+	for i, name := range names {
+		ports[i] = freePort(t)
+
+		file := filepath.Join(dir, name+".yaml")
+		content := fmt.Sprintf("service:\n  name: %s\n  port: %d\n  health-path: /health\nlog:\n  sinks:\n    - type: none\n", name, ports[i])
+
+		if err := ioutil.WriteFile(file, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		bootstraps[i] = NewBootstrap()
+		bootstraps[i].RootCommand().SetArgs([]string{"serve", "--config", file})
+
+		go func(b *Bootstrap) {
+			done <- b.Run(ctx, &testApp{})
+		}(bootstraps[i])
+	}
+
+	for i, name := range names {
+		waitForHealth(t, fmt.Sprintf("http://localhost:%d/health", ports[i]))
+
+		if got := bootstraps[i].Config().GetString(config.ServiceNameKey); got != name {
+			t.Errorf("%s = %q, want %q", config.ServiceNameKey, got, name)
+		}
+
+		if bootstraps[i].Server() == nil {
+			t.Errorf("Server() of the %s bootstrap = nil while it is running", name)
+		}
+
+		// the scheduler component is only created when jobs have been added
+		if _, ok := bootstraps[i].logLevels().ComponentLevels()["scheduler"]; ok {
+			t.Errorf("the %s bootstrap has a scheduler component without jobs", name)
+		}
+	}
+
+	if viper.IsSet(config.ServiceNameKey) {
+		t.Errorf("the global configuration has %s = %q", config.ServiceNameKey, viper.GetString(config.ServiceNameKey))
+	}
+
+	cancel()
+
+	for range names {
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("Run() error = %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Run() did not return")
+		}
+	}
+}
+
+func TestRunControl(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cmd")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "application.yaml")
+
+	if err := ioutil.WriteFile(file, []byte("service:\n  health-path: /health\nadmin:\n  enabled: true\nlog:\n  sinks:\n    - type: none\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	port := freePort(t)
+
+	tests := []struct {
+		name     string
+		posts    []string
+		controls []api.Control
+		wantCode int
+	}{
+		{"Test stop", nil, []api.Control{{Command: api.Stop, Reason: "done"}}, 0},
+		{"Test stop with status", nil, []api.Control{{Command: api.Stop, Reason: "maintenance", Status: 3}}, 3},
+		{"Test stop with error", nil, []api.Control{{Command: api.Stop, Reason: "broken", Err: errors.New("queue closed")}}, ExitFailure},
+		{"Test drain and reload before stop", nil, []api.Control{{Command: api.Drain}, {Command: api.Reload}, {Command: api.Stop}}, 0},
+		{"Test drain and reload by the admin endpoints", []string{"/admin/drain", "/admin/reload"}, []api.Control{{Command: api.Stop}}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBootstrap()
+			b.RootCommand().SetArgs([]string{"serve", "--config", file, "--port", strconv.Itoa(port)})
+
+			done := make(chan error, 1)
+
+			go func() {
+				done <- b.Run(context.Background(), &testApp{})
+			}()
+
+			url := fmt.Sprintf("http://localhost:%d/health", port)
+			waitForHealth(t, url)
+
+			server := b.Server()
+
+			// the connections are closed before the service is stopped, so that they do not delay its shutdown
+			transport := &http.Transport{}
+			client := &http.Client{Transport: transport}
+
+			for _, p := range tt.posts {
+				resp, err := client.Post(fmt.Sprintf("http://localhost:%d%s", port, p), "application/json", nil)
+
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				resp.Body.Close()
+
+				if resp.StatusCode != http.StatusAccepted {
+					t.Errorf("POST %s = %d, want %d", p, resp.StatusCode, http.StatusAccepted)
+				}
+			}
+
+			transport.CloseIdleConnections()
+
+			if len(tt.posts) > 0 && !server.Draining() {
+				t.Error("the service is not draining after POST /admin/drain")
+			}
+
+			for _, c := range tt.controls {
+				if !server.Send(c) {
+					t.Fatalf("Send(%v) = false while the service is running", c.Command)
+				}
+
+				if c.Command == api.Drain {
+					for i := 0; i < 50 && !server.Draining(); i++ {
+						time.Sleep(10 * time.Millisecond)
+					}
+
+					resp, err := http.Get(url)
+
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					resp.Body.Close()
+
+					if resp.StatusCode != http.StatusServiceUnavailable {
+						t.Errorf("health of a draining service = %d, want %d", resp.StatusCode, http.StatusServiceUnavailable)
+					}
+				}
+			}
+
+			select {
+			case err := <-done:
+				if got := ExitCode(err); got != tt.wantCode {
+					t.Errorf("Run() error = %v, exit code %d, want %d", err, got, tt.wantCode)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("Run() did not return")
+			}
+		})
+	}
+}
+
+type routesApp struct {
+	testApp
+	routes func(s *api.Server)
+}
+
+func (a *routesApp) InitializeRoutes(s *api.Server) {
+	a.routes(s)
+}
+
+func TestReloadWhileServing(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cmd")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "application.yaml")
+
+	if err := ioutil.WriteFile(file, []byte("service:\n  name: reloaded\nlog:\n  sinks:\n    - type: none\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	port := freePort(t)
+
+	app := &routesApp{routes: func(s *api.Server) {
+		s.Router.HandleFunc("/name", func(w http.ResponseWriter, r *http.Request) {
+			api.RespondWithJSON(w, http.StatusOK, s.Config().GetString(config.ServiceNameKey))
+		})
+	}}
+
+	b := NewBootstrap()
+	b.RootCommand().SetArgs([]string{"serve", "--config", file, "--port", strconv.Itoa(port)})
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- b.Run(context.Background(), app)
+	}()
+
+	url := fmt.Sprintf("http://localhost:%d/name", port)
+	waitForHealth(t, url)
+
+	// the connections are closed once the requests stop, a connection dialled but never used would delay
+	// the shutdown of the server
+	transport := &http.Transport{}
+	client := &http.Client{Transport: transport}
+
+	defer transport.CloseIdleConnections()
+
+	stop := make(chan struct{})
+	requests := make(chan error, 4)
+
+	for i := 0; i < cap(requests); i++ {
+		go func() {
+			for {
+				select {
+				case <-stop:
+					requests <- nil
+					return
+				default:
+				}
+
+				resp, err := client.Get(url)
+
+				if err != nil {
+					requests <- err
+					return
+				}
+
+				resp.Body.Close()
+			}
+		}()
+	}
+
+	server := b.Server()
+
+	for i := 0; i < 20; i++ {
+		if !server.Send(api.Control{Command: api.Reload, Reason: "test"}) {
+			t.Fatal("Send(reload) = false while the service is running")
+		}
+	}
+
+	close(stop)
+
+	for i := 0; i < cap(requests); i++ {
+		if err := <-requests; err != nil {
+			t.Errorf("GET %s error = %v", url, err)
+		}
+	}
+
+	transport.CloseIdleConnections()
+
+	if got := b.Config().GetString(config.ServiceNameKey); got != "reloaded" {
+		t.Errorf("%s = %q after reloading, want %q", config.ServiceNameKey, got, "reloaded")
+	}
+
+	server.Send(api.Control{Command: api.Stop})
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Run() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run() did not return")
+	}
+}
+
+func TestBootstrapRoutes(t *testing.T) {
+	appRoutes := func(s *api.Server) {
+		s.Router.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+			api.RespondWithJSON(w, http.StatusOK, "app")
+		})
+	}
+
+	tests := []struct {
+		name     string
+		settings map[string]interface{}
+		path     string
+		wantCode int
+		wantApp  bool
+	}{
+		{"Test info is not public by default", nil, "/info", http.StatusNotFound, false},
+		{"Test info under the admin path", map[string]interface{}{config.AdminEnabledKey: true}, "/admin/info", http.StatusOK, false},
+		{"Test info on a configured path", map[string]interface{}{config.ServiceInfoPathKey: "/version"}, "/version", http.StatusOK, false},
+		{"Test application route is not shadowed by info", map[string]interface{}{config.ServiceInfoPathKey: "/status"}, "/status", http.StatusOK, true},
+		{"Test health is not public by default", nil, "/health", http.StatusNotFound, false},
+		{"Test health under the admin path", map[string]interface{}{config.AdminEnabledKey: true}, "/admin/health", http.StatusOK, false},
+		{"Test health on a configured path", map[string]interface{}{config.ServiceHealthPathKey: "/healthz"}, "/healthz", http.StatusOK, false},
+		{"Test application route is not shadowed by health", map[string]interface{}{config.ServiceHealthPathKey: "/status"}, "/status", http.StatusOK, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBootstrap()
+
+			for k, v := range tt.settings {
+				b.Config().Set(k, v)
+			}
+
+			server := b.newServer(nil, "localhost", 0, appRoutes)
+
+			rec := httptest.NewRecorder()
+			server.Router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("GET %s = %d, want %d", tt.path, rec.Code, tt.wantCode)
+			}
+
+			if got := rec.Body.String() == `"app"`; got != tt.wantApp {
+				t.Errorf("GET %s served by the application = %v, want %v", tt.path, got, tt.wantApp)
+			}
+		})
+	}
+}
+
+func TestHealthURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings map[string]interface{}
+		admin    bool
+		want     string
+		wantErr  bool
+	}{
+		{"Test service port", map[string]interface{}{config.ServiceHealthPathKey: "/health", config.ServicePortKey: 8080}, false, "http://localhost:8080/health", false},
+		{"Test admin when not served on the service port", map[string]interface{}{config.AdminEnabledKey: true}, false, "http://localhost:9900/admin/health", false},
+		{"Test admin port", map[string]interface{}{config.AdminEnabledKey: true, config.AdminPortKey: 9901}, true, "http://localhost:9901/admin/health", false},
+		{"Test TLS", map[string]interface{}{config.ServiceHealthPathKey: "/health", config.ServiceTLSEnabledKey: true}, false, "https://localhost:9900/health", false},
+		{"Test not served", nil, false, "", true},
+		{"Test admin disabled", map[string]interface{}{config.ServiceHealthPathKey: "/health"}, true, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := config.NewSource()
+
+			for k, v := range tt.settings {
+				source.Set(k, v)
+			}
+
+			got, err := healthURL(source, tt.admin)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("healthURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("healthURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
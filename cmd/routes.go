@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/birchwood-langham/web-service-bootstrap/api"
+	"github.com/birchwood-langham/web-service-bootstrap/config"
+)
+
+// newRoutesCmd creates the command listing the endpoints of the service without starting it
+func (b *Bootstrap) newRoutesCmd() *cobra.Command {
+	var routesOutput string
+
+	routesCmd := &cobra.Command{
+		Use:   "routes",
+		Short: "List the endpoints of the service",
+		Long:  `List every route registered by the service and the bootstrap, with its methods, path template, name, middleware and matchers, without starting the service`,
+		Args:  cobra.NoArgs,
+		// the routes are written to stdout, so the logger is not set up
+		PersistentPreRun: b.initConfigOnly,
+		SilenceUsage:     true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if b.configErr != nil {
+				return b.configErr
+			}
+
+			routes, err := b.routes()
+
+			if err != nil {
+				return err
+			}
+
+			if routesOutput == "table" {
+				return writeRoutesTable(cmd, routes)
+			}
+
+			return writeOutput(cmd.OutOrStdout(), routesOutput, routes)
+		},
+	}
+
+	routesCmd.Flags().StringVarP(&routesOutput, "output", "o", "table", "output format, table, json or yaml")
+
+	return routesCmd
+}
+
+// routes returns the routes of the bootstrap and the application. The application is not initialized, so that
+// its dependencies, such as a database, are not needed to list its routes, routes that depend on Init having
+// run are reported as an error rather than crashing the command.
+func (b *Bootstrap) routes() (routes []api.RouteInfo, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("could not register the routes of the application, InitializeRoutes must not depend on Init, which is not called by the routes command: %v", r)
+		}
+	}()
+
+	server := b.newServer(nil, b.source.GetString(config.ServiceHostKey), b.source.GetInt(config.ServicePortKey), b.application.InitializeRoutes)
+
+	return server.Routes()
+}
+
+func writeRoutesTable(cmd *cobra.Command, routes []api.RouteInfo) error {
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+
+	fmt.Fprintln(w, "SERVER\tMETHODS\tPATH\tNAME\tMIDDLEWARE\tMATCHERS")
+
+	for _, r := range routes {
+		methods := "ANY"
+
+		if len(r.Methods) > 0 {
+			methods = strings.Join(r.Methods, ",")
+		}
+
+		var matchers []string
+
+		if r.Host != "" {
+			matchers = append(matchers, "host="+r.Host)
+		}
+
+		for _, q := range r.Queries {
+			matchers = append(matchers, "query:"+q)
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", r.Server, methods, dashIfEmpty(r.Path), dashIfEmpty(r.Name),
+			dashIfEmpty(strings.Join(r.Middleware, ",")), dashIfEmpty(strings.Join(matchers, " ")))
+	}
+
+	return w.Flush()
+}
+
+func dashIfEmpty(s string) string {
+	if s == "" {
+		return "-"
+	}
+
+	return s
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/birchwood-langham/web-service-bootstrap/api"
+	"github.com/birchwood-langham/web-service-bootstrap/service"
+)
+
+// initApp registers a route that depends on state set by Init
+type initApp struct {
+	testApp
+	greeting *string
+}
+
+func (a *initApp) Init() error {
+	greeting := "hello"
+	a.greeting = &greeting
+
+	return nil
+}
+
+func (a *initApp) InitializeRoutes(s *api.Server) {
+	path := "/" + *a.greeting
+
+	s.Router.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {}).Methods(http.MethodGet)
+}
+
+func TestRoutesCommand(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cmd")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "application.yaml")
+
+	if err := ioutil.WriteFile(file, []byte("service:\n  health-path: /health\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		app     service.Application
+		want    string
+		wantErr string
+	}{
+		{"Test lists the routes", &routesApp{routes: func(s *api.Server) {
+			s.Router.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {}).Methods(http.MethodGet)
+		}}, "/status", ""},
+		{"Test routes depending on Init", &initApp{}, "", "InitializeRoutes must not depend on Init"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+
+			b := NewBootstrap()
+			b.RootCommand().SetArgs([]string{"routes", "--config", file})
+			b.RootCommand().SetOut(&out)
+
+			err := b.Run(context.Background(), tt.app)
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Run() error = %v, want %q", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+
+			if !strings.Contains(out.String(), tt.want) {
+				t.Errorf("routes = %s, want %s listed", out.String(), tt.want)
+			}
+		})
+	}
+}
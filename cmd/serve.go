@@ -0,0 +1,322 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/birchwood-langham/web-service-bootstrap/api"
+	"github.com/birchwood-langham/web-service-bootstrap/config"
+	"github.com/birchwood-langham/web-service-bootstrap/service"
+)
+
+// newServeCmd creates the command starting the service, it is also run by the root command when no command is given
+func (b *Bootstrap) newServeCmd() *cobra.Command {
+	serveCmd := &cobra.Command{
+		Use:     "serve",
+		Short:   "Start the service",
+		Long:    `Start the service, the host, port, log level and timeouts given as flags override the configuration file and environment variables`,
+		Args:    cobra.NoArgs,
+		PreRunE: b.validateServe,
+		RunE:    b.runService,
+	}
+
+	addServeFlags(serveCmd.Flags())
+
+	return serveCmd
+}
+
+// serveFlags maps the flags of the serve command to the configuration keys they override
+var serveFlags = map[string]string{
+	"host":          config.ServiceHostKey,
+	"port":          config.ServicePortKey,
+	"log-level":     config.LogLevelKey,
+	"read-timeout":  config.ServiceReadTimeoutKey,
+	"write-timeout": config.ServiceWriteTimeoutKey,
+	"idle-timeout":  config.ServiceIdleTimeoutKey,
+}
+
+// addServeFlags adds the flags overriding the server settings to a command running the service
+func addServeFlags(flags *pflag.FlagSet) {
+	flags.String("host", "", "the host to run the service on")
+	flags.Int("port", 0, "the port to run the service on")
+	flags.String("log-level", "", "the logging level, one of DEBUG, INFO, WARN, ERROR, FATAL or PANIC")
+	flags.Int("read-timeout", 0, "the number of seconds before a read request will timeout")
+	flags.Int("write-timeout", 0, "the number of seconds before a write request will timeout")
+	flags.Int("idle-timeout", 0, "the number of seconds an idle keep-alive connection is kept open")
+}
+
+// bindFlags binds the flags of the command being run to the configuration keys they override, so
+// that a flag given on the command line takes precedence over the configuration file. It is called
+// before the logger is set up so that the log level flag applies to the logger.
+func (b *Bootstrap) bindFlags(cmd *cobra.Command) {
+	for name, key := range serveFlags {
+		if flag := cmd.Flags().Lookup(name); flag != nil {
+			_ = b.source.BindPFlag(key, flag)
+			b.boundFlags[key] = flag
+		}
+	}
+}
+
+// flagSource returns the flag supplying the value of a configuration key, if it was given on the command line
+func (b *Bootstrap) flagSource(key string) (string, bool) {
+	if flag, ok := b.boundFlags[key]; ok && flag.Changed {
+		return "flag --" + flag.Name, true
+	}
+
+	return "", false
+}
+
+// validateServe checks the server settings before the application is initialized
+func (b *Bootstrap) validateServe(cmd *cobra.Command, args []string) error {
+	// the flags have been parsed, an invalid setting is reported without the usage
+	cmd.SilenceUsage = true
+
+	keys := make([]string, 0, len(serveFlags))
+
+	for _, key := range serveFlags {
+		keys = append(keys, key)
+	}
+
+	return b.source.ValidateKeys(keys...)
+}
+
+// runService runs the service until it is stopped by a signal, the server or the context of the command
+func (b *Bootstrap) runService(cmd *cobra.Command, args []string) error {
+	// the context of a sub command is only set the first time it is executed, the root command
+	// holds the context given to the latest call to Run
+	ctx := cmd.Root().Context()
+
+	startCtx, cancelStart := b.withTimeout(ctx, config.ServiceStartupTimeoutKey, config.DefaultStartupTimeout)
+	defer cancelStart()
+
+	if err := b.initApplication(startCtx); err != nil {
+		// the application may have been partly initialized
+		return b.abortStart(err)
+	}
+
+	signalChannel := make(chan os.Signal, 100)
+	signal.Notify(signalChannel, syscall.SIGINT, syscall.SIGTERM)
+
+	if len(controlSignals) > 0 {
+		signal.Notify(signalChannel, controlSignals...)
+	}
+
+	defer signal.Stop(signalChannel)
+
+	b.checkConfiguration(config.ServiceHostKey, config.ServicePortKey)
+
+	serverHost := "localhost"
+
+	if b.source.IsSet(config.ServiceHostKey) {
+		serverHost = b.source.GetString(config.ServiceHostKey)
+	}
+
+	serverPort := 9900
+
+	if b.source.IsSet(config.ServicePortKey) {
+		serverPort = b.source.GetInt(config.ServicePortKey)
+	}
+
+	b.sugar().Infof("Starting service on %s:%d", serverHost, serverPort)
+
+	control := make(chan api.Control, b.source.GetInt(config.ServiceCommandBufferKey))
+
+	server := b.newServer(control, serverHost, serverPort, b.application.InitializeRoutes)
+
+	b.supervisor = service.NewSupervisor(b.Logger())
+
+	for _, w := range b.workers {
+		b.supervisor.Add(w.name, w.worker, w.policy)
+		server.AddHealthCheck("worker:"+w.name, b.supervisor.HealthCheck(w.name))
+	}
+
+	if err := b.beforeStart(startCtx); err != nil {
+		return b.abortStart(err)
+	}
+
+	if err := server.Listen(); err != nil {
+		b.sugar().Errorf("Could not start the service: %v", err)
+		return b.abortStart(fmt.Errorf("could not start the service: %w", err))
+	}
+
+	b.setServer(server)
+
+	go server.Serve()
+
+	var errs service.Errors
+
+	stop := api.Control{Command: api.Stop, Reason: "the application could not be started"}
+
+	if err := b.afterStart(startCtx, server.Addr()); err != nil {
+		errs = append(errs, err)
+	} else {
+		cancelStart()
+
+		// the workers and jobs are stopped by stopService once the server has been shut down
+		b.supervisor.Start(context.Background())
+
+		// the scheduler logger is only created, and listed with the component levels, when there are jobs
+		if b.scheduler.Len() > 0 {
+			b.scheduler.Start(context.Background(), b.jobLogger())
+		}
+
+		stop = b.waitForStop(ctx, signalChannel, control, server)
+	}
+
+	return b.stopService(server, stop, signalChannel, errs)
+}
+
+// waitForStop handles the signals sent to the service and the control messages sent by the server until the
+// service is stopped by a signal, the server or the context, it returns the message describing why it stopped
+func (b *Bootstrap) waitForStop(ctx context.Context, signalChannel chan os.Signal, control chan api.Control, server *api.Server) api.Control {
+	for {
+		select {
+		case incomingSignal := <-signalChannel:
+			if b.handleControlSignal(incomingSignal) {
+				continue
+			}
+
+			b.sugar().Infof("Caught signal %v: terminating", incomingSignal)
+
+			return api.Control{Command: api.Stop, Reason: fmt.Sprintf("caught signal %v", incomingSignal)}
+		case <-ctx.Done():
+			b.sugar().Infof("Context has been cancelled: %v, stopping service", ctx.Err())
+
+			return api.Control{Command: api.Stop, Reason: fmt.Sprintf("the context has been cancelled: %v", ctx.Err())}
+		case c := <-control:
+			switch c.Command {
+			case api.Stop:
+				b.sugar().Infof("Stop requested by the server: %s", c.Reason)
+
+				return c
+			case api.Reload:
+				b.sugar().Infof("Reload requested by the server: %s", c.Reason)
+				b.reloadConfig()
+			case api.Drain:
+				b.sugar().Infof("Drain requested by the server: %s", c.Reason)
+				server.SetDraining(true)
+			default:
+				b.sugar().Warnf("Ignoring unknown control message %v: %s", c.Command, c.Reason)
+			}
+		}
+	}
+}
+
+// abortStart cleans up the application when the service could not be started, the clean up is given the
+// shutdown timeout rather than what is left of the startup timeout
+func (b *Bootstrap) abortStart(err error) error {
+	ctx, cancel := b.withTimeout(context.Background(), config.ServiceShutdownTimeoutKey, config.DefaultShutdownTimeout)
+	defer cancel()
+
+	errs := b.cleanup(ctx, service.Errors{err})
+
+	b.closeLogger()
+
+	return errs.Err()
+}
+
+// stopService runs the BeforeShutdown hook of the application, shuts down the server, stops the jobs and
+// workers and cleans up the application within the shutdown timeout. It returns the error of the stop message,
+// or the error that stopped the server, followed by the errors of the lifecycle hooks and the clean up, with
+// the exit status requested by the stop message. A terminating signal caught while the service is shutting
+// down abandons the steps that have not completed.
+func (b *Bootstrap) stopService(server *api.Server, stop api.Control, signalChannel chan os.Signal, errs service.Errors) error {
+	b.sugar().Infof("Stopping service: %s", stop.Reason)
+
+	// the service is reported as down while it shuts down
+	server.SetDraining(true)
+
+	if err := stop.Err; err != nil {
+		errs = append(service.Errors{err}, errs...)
+	} else if err := server.Err(); err != nil {
+		errs = append(service.Errors{err}, errs...)
+	}
+
+	// the service has been asked to stop, the shutdown is not bound to the context of the command
+	ctx, cancel := b.withTimeout(context.Background(), config.ServiceShutdownTimeoutKey, config.DefaultShutdownTimeout)
+	defer cancel()
+
+	forced := make(chan os.Signal, 1)
+
+	go b.forceOnSignal(ctx, cancel, signalChannel, forced)
+
+	if err := b.beforeShutdown(ctx); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := server.Shutdown(ctx); err != nil {
+		b.sugar().Errorf("Could not shut down the server gracefully: %v", err)
+	}
+
+	if err := b.scheduler.Stop(ctx); err != nil {
+		b.sugar().Errorf("Could not stop the scheduled jobs: %v", err)
+		errs = append(errs, err)
+	}
+
+	if err := b.supervisor.Stop(ctx); err != nil {
+		b.sugar().Errorf("Could not stop the workers: %v", err)
+		errs = append(errs, err)
+	}
+
+	errs = b.cleanup(ctx, errs)
+
+	select {
+	case sig := <-forced:
+		errs = append(errs, fmt.Errorf("the shutdown was forced by signal %v", sig))
+	default:
+	}
+
+	b.setServer(nil)
+	b.closeLogger()
+
+	return withStatus(stop, errs.Err())
+}
+
+// forceOnSignal cancels the shutdown when a terminating signal is caught before it completes, control
+// signals are ignored while the service is shutting down
+func (b *Bootstrap) forceOnSignal(ctx context.Context, cancel context.CancelFunc, signalChannel chan os.Signal, forced chan os.Signal) {
+	for {
+		select {
+		case sig := <-signalChannel:
+			if isControlSignal(sig) {
+				continue
+			}
+
+			b.sugar().Warnf("Caught signal %v while shutting down: forcing exit", sig)
+
+			forced <- sig
+			cancel()
+
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// isControlSignal returns whether the signal adjusts the running service rather than terminate it
+func isControlSignal(sig os.Signal) bool {
+	for _, s := range controlSignals {
+		if s == sig {
+			return true
+		}
+	}
+
+	return false
+}
+
+// cleanup cleans up the application, adding its error to the errors
+func (b *Bootstrap) cleanup(ctx context.Context, errs service.Errors) service.Errors {
+	if err := b.cleanupApplication(ctx); err != nil {
+		b.sugar().Errorf("Could not execute cleanup - %s", err)
+		errs = append(errs, err)
+	}
+
+	return errs
+}
@@ -0,0 +1,33 @@
+// +build !windows
+
+package cmd
+
+import (
+	"os"
+	"syscall"
+)
+
+// controlSignals are the signals that adjust the running service rather than terminate it
+var controlSignals = []os.Signal{syscall.SIGHUP, syscall.SIGUSR1, syscall.SIGUSR2}
+
+// handleControlSignal reloads the configuration on SIGHUP, increases the log verbosity on SIGUSR1 and
+// decreases it on SIGUSR2. It returns false if the signal is not a control signal.
+func (b *Bootstrap) handleControlSignal(sig os.Signal) bool {
+	switch sig {
+	case syscall.SIGHUP:
+		b.reloadConfig()
+	case syscall.SIGUSR1:
+		levels := b.logLevels()
+		level := levels.IncreaseVerbosity(levels.RevertAfter())
+		b.sugar().Warnf("Caught signal %v: log level increased to %s", sig, level.CapitalString())
+	case syscall.SIGUSR2:
+		levels := b.logLevels()
+		level := levels.DecreaseVerbosity(levels.RevertAfter())
+		// logged as a warning so that the change is visible at the new level
+		b.sugar().Warnf("Caught signal %v: log level decreased to %s", sig, level.CapitalString())
+	default:
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,192 @@
+// +build !windows
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+
+	"github.com/birchwood-langham/web-service-bootstrap/logger"
+)
+
+func TestSignalStopsService(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cmd")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	// the control channel is not buffered, as service.api-command-buffer is not set
+	file := filepath.Join(dir, "application.yaml")
+
+	if err := ioutil.WriteFile(file, []byte("service:\n  health-path: /health\nlog:\n  sinks:\n    - type: none\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	port := freePort(t)
+
+	b := NewBootstrap()
+	b.RootCommand().SetArgs([]string{"serve", "--config", file, "--port", strconv.Itoa(port)})
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- b.Run(context.Background(), &testApp{})
+	}()
+
+	waitForHealth(t, fmt.Sprintf("http://localhost:%d/health", port))
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Run() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run() did not return after SIGTERM")
+	}
+}
+
+func TestSecondSignalForcesShutdown(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cmd")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	// the shutdown timeout is long enough that only the second signal ends the blocked clean up
+	file := filepath.Join(dir, "application.yaml")
+
+	if err := ioutil.WriteFile(file, []byte("service:\n  health-path: /health\n  shutdown-timeout: 1m\nlog:\n  sinks:\n    - type: none\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	port := freePort(t)
+	app := &contextApp{blockCleanup: true}
+
+	b := NewBootstrap()
+	b.RootCommand().SetArgs([]string{"serve", "--config", file, "--port", strconv.Itoa(port)})
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- b.Run(context.Background(), app)
+	}()
+
+	waitForHealth(t, fmt.Sprintf("http://localhost:%d/health", port))
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if cleaned, _ := app.cleanedUp(); cleaned {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("the application was not cleaned up after SIGTERM")
+		}
+	}
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err := <-done:
+		if err == nil || !strings.Contains(err.Error(), "the shutdown was forced by signal") {
+			t.Errorf("Run() error = %v, want the shutdown to have been forced", err)
+		}
+
+		if code := ExitCode(err); code == 0 {
+			t.Errorf("ExitCode() = %d, want a non-zero exit code", code)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run() did not return after the second SIGTERM")
+	}
+}
+
+func TestSignalChangesLogLevel(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cmd")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "application.yaml")
+
+	if err := ioutil.WriteFile(file, []byte("service:\n  health-path: /health\nlog:\n  level: INFO\n  sinks:\n    - type: none\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	port := freePort(t)
+
+	b := NewBootstrap()
+	b.RootCommand().SetArgs([]string{"serve", "--config", file, "--port", strconv.Itoa(port)})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+
+	go func() {
+		done <- b.Run(ctx, &testApp{})
+	}()
+
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	waitForHealth(t, fmt.Sprintf("http://localhost:%d/health", port))
+
+	tests := []struct {
+		name   string
+		signal syscall.Signal
+		want   zapcore.Level
+	}{
+		{"Test SIGUSR1 increases verbosity", syscall.SIGUSR1, zapcore.DebugLevel},
+		{"Test SIGUSR2 decreases verbosity", syscall.SIGUSR2, zapcore.InfoLevel},
+		{"Test SIGUSR2 again", syscall.SIGUSR2, zapcore.WarnLevel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := syscall.Kill(os.Getpid(), tt.signal); err != nil {
+				t.Fatal(err)
+			}
+
+			deadline := time.Now().Add(5 * time.Second)
+
+			for b.logLevels().Level().Level() != tt.want && time.Now().Before(deadline) {
+				time.Sleep(10 * time.Millisecond)
+			}
+
+			if got := b.logLevels().Level().Level(); got != tt.want {
+				t.Errorf("log level = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if got := logger.Level().Level(); got != zapcore.InfoLevel {
+		t.Errorf("global log level = %v, want the signals to change the level of the bootstrap only", got)
+	}
+}
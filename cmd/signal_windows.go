@@ -0,0 +1,11 @@
+package cmd
+
+import "os"
+
+// controlSignals are the signals that adjust the running service rather than terminate it,
+// there are none on windows
+var controlSignals []os.Signal
+
+func (b *Bootstrap) handleControlSignal(sig os.Signal) bool {
+	return false
+}
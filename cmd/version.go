@@ -16,23 +16,58 @@ package cmd
 
 import (
 	"fmt"
-
-	"github.com/birchwood-langham/web-service-bootstrap/config"
-	"github.com/spf13/viper"
+	"text/tabwriter"
 
 	"github.com/spf13/cobra"
+
+	"github.com/birchwood-langham/web-service-bootstrap/version"
 )
 
-// versionCmd represents the version command
-var versionCmd = &cobra.Command{
-	Use:   "version",
-	Short: "Get the current version number",
-	Long:  `Get the current version number of the trading strategy`,
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Print(viper.Get(config.VersionKey))
-	},
+// newVersionCmd creates the version command
+func (b *Bootstrap) newVersionCmd() *cobra.Command {
+	var versionOutput string
+
+	versionCmd := &cobra.Command{
+		Use:   "version",
+		Short: "Get the current version number",
+		Long:  `Get the version, git commit and build date of the application binary`,
+		Args:  cobra.NoArgs,
+		// the version is reported by the binary rather than the configuration, so the logger is not set up
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+		SilenceUsage:     true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			info := version.Get()
+
+			switch versionOutput {
+			case "text":
+				w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+
+				fmt.Fprintf(w, "Version:\t%s\n", info.Version)
+				fmt.Fprintf(w, "Commit:\t%s\n", valueOrUnknown(info.Commit))
+				fmt.Fprintf(w, "Built:\t%s\n", valueOrUnknown(info.Date))
+				fmt.Fprintf(w, "Dirty:\t%t\n", info.Dirty)
+				fmt.Fprintf(w, "Go version:\t%s\n", info.GoVersion)
+				fmt.Fprintf(w, "Platform:\t%s\n", info.Platform)
+
+				return w.Flush()
+			case "short":
+				_, err := fmt.Fprintln(cmd.OutOrStdout(), info.Version)
+				return err
+			default:
+				return writeOutput(cmd.OutOrStdout(), versionOutput, info)
+			}
+		},
+	}
+
+	versionCmd.Flags().StringVarP(&versionOutput, "output", "o", "text", "output format, text, short, json or yaml")
+
+	return versionCmd
 }
 
-func init() {
-	AddCommand(versionCmd)
+func valueOrUnknown(value string) string {
+	if value == "" {
+		return "unknown"
+	}
+
+	return value
 }
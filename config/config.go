@@ -25,6 +25,22 @@ const (
 	ServiceReadTimeoutKey = "service.read-timeout-seconds"
 	// ServiceIdleTimeoutKey is the application.yaml key for retrieving the idle timeout for the server
 	ServiceIdleTimeoutKey = "service.idle-timeout-seconds"
+	// ServiceInfoPathKey is the application.yaml key for retrieving the path of the endpoint reporting the version of the service
+	ServiceInfoPathKey = "service.info-path"
+	// ServiceHealthPathKey is the application.yaml key for retrieving the path of the endpoint reporting the health of the service
+	ServiceHealthPathKey = "service.health-path"
+	// ServiceTLSEnabledKey is the application.yaml key for retrieving whether the service is served over TLS
+	ServiceTLSEnabledKey = "service.tls.enabled"
+	// ServiceTLSCertFileKey is the application.yaml key for retrieving the path of the certificate file used to serve TLS
+	ServiceTLSCertFileKey = "service.tls.cert-file"
+	// ServiceTLSKeyFileKey is the application.yaml key for retrieving the path of the private key file used to serve TLS
+	ServiceTLSKeyFileKey = "service.tls.key-file"
+	// ServiceHookTimeoutKey is the application.yaml key for retrieving how long each lifecycle hook of the application may run
+	ServiceHookTimeoutKey = "service.hook-timeout"
+	// ServiceStartupTimeoutKey is the application.yaml key for retrieving how long the application may take to start
+	ServiceStartupTimeoutKey = "service.startup-timeout"
+	// ServiceShutdownTimeoutKey is the application.yaml key for retrieving how long the application may take to shut down
+	ServiceShutdownTimeoutKey = "service.shutdown-timeout"
 	// LogFilePathKey is the application.yaml key for retrieving the path for the log file generated by the service
 	LogFilePathKey = "log.filepath"
 	// LogLevelKey is the application.yaml key for retrieving the logging level
@@ -37,242 +53,255 @@ const (
 	LogFileMaxAge = "log.max-age"
 	// LogFileCompress is the application yaml key for retrieving the log file compression configuration
 	LogFileCompress = "log.compress"
+	// LogFormatKey is the application.yaml key for retrieving the default format of the log sinks
+	LogFormatKey = "log.format"
+	// LogSinksKey is the application.yaml key for retrieving the list of sinks log entries are written to
+	LogSinksKey = "log.sinks"
+	// LogLevelsKey is the application.yaml key for retrieving the logging levels of the named components of the application
+	LogLevelsKey = "log.levels"
+	// LogLevelRevertAfterKey is the application.yaml key for retrieving how long a log level changed at runtime lasts before it is reverted
+	LogLevelRevertAfterKey = "log.level-revert-after"
+	// LogRedactEnabledKey is the application.yaml key for retrieving whether sensitive fields are redacted from log entries
+	LogRedactEnabledKey = "log.redact.enabled"
+	// LogRedactKeysKey is the application.yaml key for retrieving the additional field names whose values are redacted from log entries
+	LogRedactKeysKey = "log.redact.keys"
+	// LogRedactHeadersKey is the application.yaml key for retrieving the additional HTTP headers whose values are redacted from log entries
+	LogRedactHeadersKey = "log.redact.headers"
+	// LogRedactPatternsKey is the application.yaml key for retrieving the patterns of values that are redacted from log entries
+	LogRedactPatternsKey = "log.redact.patterns"
+	// LogSamplingInitialKey is the application.yaml key for retrieving the number of entries with the same level and message logged each tick before sampling starts
+	LogSamplingInitialKey = "log.sampling.initial"
+	// LogSamplingThereafterKey is the application.yaml key for retrieving the sampling rate of the entries logged after the initial entries of a tick
+	LogSamplingThereafterKey = "log.sampling.thereafter"
+	// LogSamplingTickKey is the application.yaml key for retrieving the period over which entries are sampled
+	LogSamplingTickKey = "log.sampling.tick"
+	// LogSamplingLevelsKey is the application.yaml key for retrieving the sampling of individual log levels
+	LogSamplingLevelsKey = "log.sampling.levels"
+	// LogSamplingDedupKey is the application.yaml key for retrieving the interval over which repeated log messages are collapsed into a summary
+	LogSamplingDedupKey = "log.sampling.dedup"
+	// AdminEnabledKey is the application.yaml key for retrieving whether the administrative endpoints are served
+	AdminEnabledKey = "admin.enabled"
+	// AdminPortKey is the application.yaml key for retrieving the port the administrative endpoints are served on
+	AdminPortKey = "admin.port"
 	// DefaultWriteTimeout is the number of seconds before a write request will timeout if an alternative has not been specified in the configuration file
 	DefaultWriteTimeout int = 20
 	// DefaultReadTimeout is the number of seconds before a write request will timeout if an alternative has not been specified in the configuration file
 	DefaultReadTimeout int = 20
 	// DefaultIdleTimeout is the number of seconds before a write request will timeout if an alternative has not been specified in the configuration file
 	DefaultIdleTimeout int = 60
+	// DefaultHookTimeout is how long each lifecycle hook of the application may run if an alternative has not been specified in the configuration file
+	DefaultHookTimeout = 30 * time.Second
+	// DefaultStartupTimeout is how long the application may take to start if an alternative has not been specified in the configuration file
+	DefaultStartupTimeout = time.Minute
+	// DefaultShutdownTimeout is how long the application may take to shut down if an alternative has not been specified in the configuration file
+	DefaultShutdownTimeout = 30 * time.Second
+	// DefaultInfoPath is the path, under the admin path, of the endpoint reporting the version of the service, it is only served on the service port if a path has been specified in the configuration file
+	DefaultInfoPath = "/info"
+	// DefaultHealthPath is the path, under the admin path, of the endpoint reporting the health of the service, it is only served on the service port if a path has been specified in the configuration file
+	DefaultHealthPath = "/health"
 )
 
 type Config struct {
-	path []string
+	path   []string
+	source *Source
 }
 
+// Get returns the typed accessor of the value at the given path in the global source
 func Get(path ...string) *Config {
-	return &Config{path: path}
+	return Global().Config(path...)
 }
 
 func (c *Config) String(d string) string {
-	k := mkString(".", c.path...)
-
-	if viper.IsSet(k) {
-		return viper.GetString(k)
-	}
+	c.read(func(v *viper.Viper, k string) {
+		d = v.GetString(k)
+	})
 
 	return d
 }
 
 func (c *Config) Int(d int) int {
-	k := mkString(".", c.path...)
-
-	if viper.IsSet(k) {
-		return viper.GetInt(k)
-	}
+	c.read(func(v *viper.Viper, k string) {
+		d = v.GetInt(k)
+	})
 
 	return d
 }
 
 func (c *Config) Int8(d int8) int8 {
-	k := mkString(".", c.path...)
+	c.read(func(v *viper.Viper, k string) {
+		value := v.GetInt32(k)
 
-	if viper.IsSet(k) {
-		v := viper.GetInt32(k)
-
-		if v >= math.MinInt8 && v <= math.MaxInt8 {
-			return int8(v)
+		if value >= math.MinInt8 && value <= math.MaxInt8 {
+			d = int8(value)
 		}
-	}
+	})
 
 	return d
 }
 
 func (c *Config) Int16(d int16) int16 {
-	k := mkString(".", c.path...)
-
-	if viper.IsSet(k) {
-		v := viper.GetInt32(k)
+	c.read(func(v *viper.Viper, k string) {
+		value := v.GetInt32(k)
 
-		if v >= math.MinInt16 && v <= math.MaxInt16 {
-			return int16(v)
+		if value >= math.MinInt16 && value <= math.MaxInt16 {
+			d = int16(value)
 		}
-	}
+	})
 
 	return d
 }
 
 func (c *Config) Int32(d int32) int32 {
-	k := mkString(".", c.path...)
-
-	if viper.IsSet(k) {
-		return viper.GetInt32(k)
-	}
+	c.read(func(v *viper.Viper, k string) {
+		d = v.GetInt32(k)
+	})
 
 	return d
 }
 
 func (c *Config) Int64(d int64) int64 {
-	k := mkString(".", c.path...)
-
-	if viper.IsSet(k) {
-		return viper.GetInt64(k)
-	}
+	c.read(func(v *viper.Viper, k string) {
+		d = v.GetInt64(k)
+	})
 
 	return d
 }
 
 func (c *Config) Value(d interface{}) interface{} {
-	k := mkString(".", c.path...)
-
-	if viper.IsSet(k) {
-		return viper.Get(k)
-	}
+	c.read(func(v *viper.Viper, k string) {
+		d = v.Get(k)
+	})
 
 	return d
 }
 
 func (c *Config) Bool(d bool) bool {
-	k := mkString(".", c.path...)
-
-	if viper.IsSet(k) {
-		return viper.GetBool(k)
-	}
+	c.read(func(v *viper.Viper, k string) {
+		d = v.GetBool(k)
+	})
 
 	return d
 }
 
 func (c *Config) Float64(d float64) float64 {
-	k := mkString(".", c.path...)
-
-	if viper.IsSet(k) {
-		return viper.GetFloat64(k)
-	}
+	c.read(func(v *viper.Viper, k string) {
+		d = v.GetFloat64(k)
+	})
 
 	return d
 }
 
 func (c *Config) Float32(d float32) float32 {
-	k := mkString(".", c.path...)
-
-	if viper.IsSet(k) {
-		v := viper.GetFloat64(k)
+	c.read(func(v *viper.Viper, k string) {
+		value := v.GetFloat64(k)
 
-		if v >= -math.MaxFloat32 && v <= math.MaxFloat32 {
-			return float32(v)
+		if value >= -math.MaxFloat32 && value <= math.MaxFloat32 {
+			d = float32(value)
 		}
-	}
+	})
 
 	return d
 }
 
 func (c *Config) StringMap(d map[string]interface{}) map[string]interface{} {
-	k := mkString(".", c.path...)
-
-	if viper.IsSet(k) {
-		return viper.GetStringMap(k)
-	}
+	c.read(func(v *viper.Viper, k string) {
+		d = v.GetStringMap(k)
+	})
 
 	return d
 }
 
 func (c *Config) StringMapString(d map[string]string) map[string]string {
-	k := mkString(".", c.path...)
-
-	if viper.IsSet(k) {
-		return viper.GetStringMapString(k)
-	}
+	c.read(func(v *viper.Viper, k string) {
+		d = v.GetStringMapString(k)
+	})
 
 	return d
 }
 
 func (c *Config) StringSlice(d []string) []string {
-	k := mkString(".", c.path...)
-
-	if viper.IsSet(k) {
-		return viper.GetStringSlice(k)
-	}
+	c.read(func(v *viper.Viper, k string) {
+		d = v.GetStringSlice(k)
+	})
 
 	return d
 }
 
 func (c *Config) Time(d time.Time) time.Time {
-	k := mkString(".", c.path...)
-
-	if viper.IsSet(k) {
-		return viper.GetTime(k)
-	}
+	c.read(func(v *viper.Viper, k string) {
+		d = v.GetTime(k)
+	})
 
 	return d
 }
 
 func (c *Config) Duration(d time.Duration) time.Duration {
-	k := mkString(".", c.path...)
-
-	if viper.IsSet(k) {
-		return viper.GetDuration(k)
-	}
+	c.read(func(v *viper.Viper, k string) {
+		d = v.GetDuration(k)
+	})
 
 	return d
 }
 
 func (c *Config) Uint(d uint) uint {
-	k := mkString(".", c.path...)
-
-	if viper.IsSet(k) {
-		return viper.GetUint(k)
-	}
+	c.read(func(v *viper.Viper, k string) {
+		d = v.GetUint(k)
+	})
 
 	return d
 }
 
 func (c *Config) Uint8(d uint8) uint8 {
-	k := mkString(".", c.path...)
+	c.read(func(v *viper.Viper, k string) {
+		value := v.GetUint(k)
 
-	if viper.IsSet(k) {
-		v := viper.GetUint(k)
-
-		if v <= math.MaxUint8 {
-			return uint8(v)
+		if value <= math.MaxUint8 {
+			d = uint8(value)
 		}
-	}
+	})
 
 	return d
 }
 
 func (c *Config) Uint16(d uint16) uint16 {
-	k := mkString(".", c.path...)
-
-	if viper.IsSet(k) {
-		v := viper.GetUint(k)
+	c.read(func(v *viper.Viper, k string) {
+		value := v.GetUint(k)
 
-		if v <= math.MaxUint16 {
-			return uint16(v)
+		if value <= math.MaxUint16 {
+			d = uint16(value)
 		}
-	}
+	})
 
 	return d
 }
 
 func (c *Config) Uint32(d uint32) uint32 {
-	k := mkString(".", c.path...)
-
-	if viper.IsSet(k) {
-		return viper.GetUint32(k)
-	}
+	c.read(func(v *viper.Viper, k string) {
+		d = v.GetUint32(k)
+	})
 
 	return d
 }
 
 func (c *Config) Uint64(d uint64) uint64 {
-	k := mkString(".", c.path...)
-
-	if viper.IsSet(k) {
-		return viper.GetUint64(k)
-	}
+	c.read(func(v *viper.Viper, k string) {
+		d = v.GetUint64(k)
+	})
 
 	return d
 }
 
+// read calls fn with the key of the value at the path if it has been set, the source cannot be reloaded while fn runs
+func (c *Config) read(fn func(v *viper.Viper, k string)) {
+	k := mkString(".", c.path...)
+
+	c.source.read(func(v *viper.Viper) {
+		if v.IsSet(k) {
+			fn(v, k)
+		}
+	})
+}
+
 func mkString(sep string, input ...string) string {
 	b := strings.Builder{}
 
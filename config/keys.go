@@ -0,0 +1,427 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cast"
+	"gopkg.in/yaml.v2"
+)
+
+// Key describes a configuration key understood by the application
+type Key struct {
+	// Name is the full path of the key, e.g. service.port
+	Name string
+	// Default is the value used when the key has not been configured
+	Default interface{}
+	// Description explains what the key configures
+	Description string
+	// Validate checks the configured value, it may be nil if any value is acceptable
+	Validate func(value interface{}) error
+}
+
+// ValidationError lists the problems found when validating the configuration
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid configuration:\n  %s", strings.Join(e.Problems, "\n  "))
+}
+
+var keyLock sync.RWMutex
+var keys = make(map[string]Key)
+
+func init() {
+	RegisterKeys(
+		Key{Name: VersionKey, Default: "0.0.1", Description: "The version number of the application"},
+		Key{Name: ServiceNameKey, Default: "Unspecified", Description: "The name of the service"},
+		Key{Name: ServiceHostKey, Default: "localhost", Description: "The host exposed by the service"},
+		Key{Name: ServicePortKey, Default: 9900, Description: "The port to run the service on", Validate: IntRange(1, int(^uint16(0)))},
+		Key{Name: ServiceCommandBufferKey, Default: 0, Description: "The size of the command buffer for sending control messages to the service", Validate: IntRange(0, maxInt)},
+		Key{Name: ServiceWriteTimeoutKey, Default: DefaultWriteTimeout, Description: "The number of seconds before a write request will timeout", Validate: IntRange(0, maxInt)},
+		Key{Name: ServiceReadTimeoutKey, Default: DefaultReadTimeout, Description: "The number of seconds before a read request will timeout", Validate: IntRange(0, maxInt)},
+		Key{Name: ServiceIdleTimeoutKey, Default: DefaultIdleTimeout, Description: "The number of seconds an idle keep-alive connection is kept open", Validate: IntRange(0, maxInt)},
+		Key{Name: ServiceInfoPathKey, Default: "", Description: "The path of the endpoint reporting the version, git commit and build date of the service on the service port, an empty path only serves it at /admin/info"},
+		Key{Name: ServiceHealthPathKey, Default: "", Description: "The path of the endpoint reporting the health of the service on the service port, an empty path only serves it at /admin/health"},
+		Key{Name: ServiceTLSEnabledKey, Default: false, Description: "Whether the service and administrative endpoints are served over TLS", Validate: IsBool},
+		Key{Name: ServiceTLSCertFileKey, Default: "", Description: "The path of the PEM encoded certificate file used to serve TLS"},
+		Key{Name: ServiceTLSKeyFileKey, Default: "", Description: "The path of the PEM encoded private key file used to serve TLS"},
+		Key{Name: ServiceHookTimeoutKey, Default: "30s", Description: "How long each lifecycle hook of the application, such as BeforeStart and BeforeShutdown, may run before it is abandoned, 0s waits for it to complete", Validate: IsDuration},
+		Key{Name: ServiceStartupTimeoutKey, Default: "1m", Description: "How long the application may take to start, from Init until AfterStart has returned, before it is abandoned, 0s waits for it to start", Validate: IsDuration},
+		Key{Name: ServiceShutdownTimeoutKey, Default: "30s", Description: "How long the application may take to shut down, from BeforeShutdown until Cleanup has returned, including draining the requests being handled, 0s waits for it to shut down", Validate: IsDuration},
+		Key{Name: LogFilePathKey, Default: "", Description: "The path of the log file generated by the service, defaults to <processname>-lumberjack.log in the temp directory"},
+		Key{Name: LogLevelKey, Default: "INFO", Description: "The logging level, one of DEBUG, INFO, WARN, ERROR, FATAL or PANIC", Validate: OneOf("DEBUG", "INFO", "WARN", "ERROR", "FATAL", "PANIC")},
+		Key{Name: LogFileMaxSize, Default: 100, Description: "The maximum size in megabytes of the log file before it is rotated", Validate: IntRange(0, maxInt)},
+		Key{Name: LogFileMaxBackups, Default: 0, Description: "The maximum number of rotated log files to retain, 0 retains all of them", Validate: IntRange(0, maxInt)},
+		Key{Name: LogFileMaxAge, Default: 0, Description: "The maximum number of days to retain rotated log files, 0 retains them regardless of age", Validate: IntRange(0, maxInt)},
+		Key{Name: LogFileCompress, Default: false, Description: "Whether rotated log files are compressed", Validate: IsBool},
+		Key{Name: LogFormatKey, Default: "console", Description: "The default format of the log sinks, one of console, json or logfmt", Validate: OneOf("console", "json", "logfmt")},
+		Key{Name: LogSinksKey, Default: []interface{}{}, Description: "The sinks log entries are written to, each with a type (stdout, stderr, file, syslog, journald or none) and an optional format, level and color, by default entries are written to the log file and stdout"},
+		Key{Name: LogLevelsKey, Default: map[string]string{}, Description: "The logging levels of named components, e.g. db: DEBUG, components that are not listed use log.level", Validate: levelMap},
+		Key{Name: LogLevelRevertAfterKey, Default: "0s", Description: "How long a log level changed at runtime lasts before it is reverted, 0s keeps the new level", Validate: IsDuration},
+		Key{Name: LogRedactEnabledKey, Default: true, Description: "Whether sensitive fields, such as passwords, tokens and credit card numbers, are redacted from log entries", Validate: IsBool},
+		Key{Name: LogRedactKeysKey, Default: []string{}, Description: "Field names whose values are redacted from log entries, in addition to names containing password, secret, token, authorization, cookie, credential, private key or api key", Validate: IsStringList},
+		Key{Name: LogRedactHeadersKey, Default: []string{}, Description: "HTTP headers whose values are redacted from logged requests, in addition to Authorization, Proxy-Authorization, Cookie, Set-Cookie and X-Api-Key", Validate: IsStringList},
+		Key{Name: LogRedactPatternsKey, Default: []string{"credit-card", "email"}, Description: "Values matching these patterns are redacted from log messages and fields, either credit-card, email or a regular expression", Validate: regexList},
+		Key{Name: LogSamplingInitialKey, Default: 0, Description: "The number of entries with the same level and message written each tick before sampling starts, 0 disables sampling", Validate: IntRange(0, maxInt)},
+		Key{Name: LogSamplingThereafterKey, Default: 0, Description: "After the initial entries of a tick, every nth entry with the same level and message is written, 0 drops them", Validate: IntRange(0, maxInt)},
+		Key{Name: LogSamplingTickKey, Default: "1s", Description: "The period over which entries are counted for sampling", Validate: IsDuration},
+		Key{Name: LogSamplingLevelsKey, Default: map[string]interface{}{}, Description: "The sampling of individual levels, e.g. debug: {initial: 10, thereafter: 100}, overriding log.sampling.initial and log.sampling.thereafter", Validate: samplingLevels},
+		Key{Name: LogSamplingDedupKey, Default: "0s", Description: "Repeated messages within the interval are written once, followed by a \"repeated N times\" summary at the end of the interval, 0s disables it", Validate: IsDuration},
+		Key{Name: AdminEnabledKey, Default: false, Description: "Whether the administrative endpoints are served", Validate: IsBool},
+		Key{Name: AdminPortKey, Default: 0, Description: "The port to serve the administrative endpoints on, 0 serves them under /admin on the service port", Validate: IntRange(0, int(^uint16(0)))},
+	)
+}
+
+const maxInt = int(^uint(0) >> 1)
+
+// RegisterKeys adds keys to the set of configuration keys known by the application, so that they are
+// validated, listed, and written to the default configuration file by the config commands
+func RegisterKeys(k ...Key) {
+	keyLock.Lock()
+	defer keyLock.Unlock()
+
+	for _, key := range k {
+		key.Name = strings.ToLower(key.Name)
+		keys[key.Name] = key
+	}
+}
+
+// Keys returns the registered configuration keys sorted by name
+func Keys() []Key {
+	keyLock.RLock()
+	defer keyLock.RUnlock()
+
+	result := make([]Key, 0, len(keys))
+
+	for _, k := range keys {
+		result = append(result, k)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return lessPath(strings.Split(result[i].Name, "."), strings.Split(result[j].Name, "."))
+	})
+
+	return result
+}
+
+func lessPath(a, b []string) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+
+	return len(a) < len(b)
+}
+
+// UnknownKeys returns the keys configured in the global source that have not been registered
+func UnknownKeys() []string {
+	return Global().UnknownKeys()
+}
+
+// UnknownKeys returns the configured keys that have not been registered
+func (s *Source) UnknownKeys() []string {
+	keyLock.RLock()
+	defer keyLock.RUnlock()
+
+	var unknown []string
+
+	for _, k := range s.AllKeys() {
+		if _, ok := keys[k]; ok || registeredParent(k) {
+			continue
+		}
+
+		unknown = append(unknown, k)
+	}
+
+	sort.Strings(unknown)
+
+	return unknown
+}
+
+// registeredParent reports whether one of the parents of the key has been registered,
+// for example a key that configures a map of values
+func registeredParent(key string) bool {
+	for i := strings.LastIndex(key, "."); i > 0; i = strings.LastIndex(key[:i], ".") {
+		if _, ok := keys[key[:i]]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Validate checks the value of each registered key in the global source, see Source.Validate
+func Validate() error {
+	return Global().Validate()
+}
+
+// ValidateKeys checks the values of the given registered keys in the global source, see Source.ValidateKeys
+func ValidateKeys(names ...string) error {
+	return Global().ValidateKeys(names...)
+}
+
+// Validate checks the configured value of each registered key, returning a ValidationError
+// listing every problem found
+func (s *Source) Validate() error {
+	return s.validate(Keys())
+}
+
+// ValidateKeys checks the configured values of the given registered keys, keys that have not
+// been registered are ignored
+func (s *Source) ValidateKeys(names ...string) error {
+	var selected []Key
+
+	keyLock.RLock()
+
+	for _, name := range names {
+		if k, ok := keys[strings.ToLower(name)]; ok {
+			selected = append(selected, k)
+		}
+	}
+
+	keyLock.RUnlock()
+
+	return s.validate(selected)
+}
+
+func (s *Source) validate(selected []Key) error {
+	var problems []string
+
+	for _, k := range selected {
+		if k.Validate == nil || !s.IsSet(k.Name) {
+			continue
+		}
+
+		if err := k.Validate(s.Get(k.Name)); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", k.Name, err))
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+
+	return nil
+}
+
+// IntRange returns a validator that checks the value is an integer between min and max inclusive
+func IntRange(min, max int) func(interface{}) error {
+	return func(value interface{}) error {
+		i, err := cast.ToIntE(value)
+
+		if err != nil {
+			return fmt.Errorf("%v is not an integer", value)
+		}
+
+		if i < min || i > max {
+			return fmt.Errorf("%d must be between %d and %d", i, min, max)
+		}
+
+		return nil
+	}
+}
+
+// OneOf returns a validator that checks the value is one of the given values, ignoring case
+func OneOf(values ...string) func(interface{}) error {
+	return func(value interface{}) error {
+		s := cast.ToString(value)
+
+		for _, v := range values {
+			if strings.EqualFold(s, v) {
+				return nil
+			}
+		}
+
+		return fmt.Errorf("%v must be one of %s", value, strings.Join(values, ", "))
+	}
+}
+
+func levelMap(value interface{}) error {
+	levels, err := cast.ToStringMapStringE(value)
+
+	if err != nil {
+		return fmt.Errorf("%v is not a map of component names to levels", value)
+	}
+
+	validLevel := OneOf("DEBUG", "INFO", "WARN", "ERROR", "FATAL", "PANIC")
+
+	for name, level := range levels {
+		if err := validLevel(level); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// IsStringList validates that the value is a list of strings
+func IsStringList(value interface{}) error {
+	if _, err := cast.ToStringSliceE(value); err != nil {
+		return fmt.Errorf("%v is not a list of strings", value)
+	}
+
+	return nil
+}
+
+func regexList(value interface{}) error {
+	patterns, err := cast.ToStringSliceE(value)
+
+	if err != nil {
+		return fmt.Errorf("%v is not a list of patterns", value)
+	}
+
+	for _, p := range patterns {
+		if _, err := regexp.Compile(p); err != nil {
+			return fmt.Errorf("%s is not a valid pattern: %w", p, err)
+		}
+	}
+
+	return nil
+}
+
+func samplingLevels(value interface{}) error {
+	levels, err := cast.ToStringMapE(value)
+
+	if err != nil {
+		return fmt.Errorf("%v is not a map of levels to sampling settings", value)
+	}
+
+	validLevel := OneOf("DEBUG", "INFO", "WARN", "ERROR", "DPANIC", "PANIC", "FATAL")
+	validCount := IntRange(0, maxInt)
+
+	for level, settings := range levels {
+		if err := validLevel(level); err != nil {
+			return err
+		}
+
+		s, err := cast.ToStringMapE(settings)
+
+		if err != nil {
+			return fmt.Errorf("%s: %v is not a map of sampling settings", level, settings)
+		}
+
+		for name, v := range s {
+			if name != "initial" && name != "thereafter" {
+				return fmt.Errorf("%s: unknown sampling setting %s", level, name)
+			}
+
+			if err := validCount(v); err != nil {
+				return fmt.Errorf("%s.%s: %w", level, name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// IsBool validates that the value is a boolean
+func IsBool(value interface{}) error {
+	if _, err := cast.ToBoolE(value); err != nil {
+		return fmt.Errorf("%v is not a boolean", value)
+	}
+
+	return nil
+}
+
+// IsDuration validates that the value is a duration such as 10s or 5m
+func IsDuration(value interface{}) error {
+	if _, err := cast.ToDurationE(value); err != nil {
+		return fmt.Errorf("%v is not a duration", value)
+	}
+
+	return nil
+}
+
+// WriteDefaults writes a YAML configuration file containing the default value of every
+// registered key, with the key descriptions as comments
+func WriteDefaults(w io.Writer) error {
+	var parent []string
+
+	for _, k := range Keys() {
+		path := strings.Split(k.Name, ".")
+
+		// find the depth of the common parent of this key and the previous key
+		depth := 0
+
+		for depth < len(parent) && depth < len(path)-1 && parent[depth] == path[depth] {
+			depth++
+		}
+
+		for ; depth < len(path)-1; depth++ {
+			if _, err := fmt.Fprintf(w, "%s%s:\n", indent(depth), path[depth]); err != nil {
+				return err
+			}
+		}
+
+		value, err := yamlValue(k.Default, depth+1)
+
+		if err != nil {
+			return fmt.Errorf("%s: %w", k.Name, err)
+		}
+
+		if k.Description != "" {
+			if _, err := fmt.Fprintf(w, "%s# %s\n", indent(depth), k.Description); err != nil {
+				return err
+			}
+		}
+
+		if _, err := fmt.Fprintf(w, "%s%s:%s\n", indent(depth), path[depth], value); err != nil {
+			return err
+		}
+
+		parent = path[:len(path)-1]
+	}
+
+	return nil
+}
+
+func indent(depth int) string {
+	return strings.Repeat("  ", depth)
+}
+
+// yamlValue formats a default value so that it can be written after a key in a YAML file,
+// values that span multiple lines are indented to the given depth
+func yamlValue(value interface{}, depth int) (string, error) {
+	if value == nil {
+		return "", nil
+	}
+
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if reflect.ValueOf(value).Len() == 0 {
+			if reflect.ValueOf(value).Kind() == reflect.Map {
+				return " {}", nil
+			}
+
+			return " []", nil
+		}
+	}
+
+	b, err := yaml.Marshal(value)
+
+	if err != nil {
+		return "", err
+	}
+
+	s := strings.TrimRight(string(b), "\n")
+
+	if strings.Contains(s, "\n") {
+		lines := strings.Split(s, "\n")
+
+		for i := range lines {
+			lines[i] = indent(depth) + lines[i]
+		}
+
+		return "\n" + strings.Join(lines, "\n"), nil
+	}
+
+	return " " + s, nil
+}
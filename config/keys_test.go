@@ -0,0 +1,106 @@
+package config
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings map[string]interface{}
+		keys     []string
+		problems int
+	}{
+		{"Test valid", map[string]interface{}{ServicePortKey: 8080, LogLevelKey: "debug", LogFileCompress: "true"}, nil, 0},
+		{"Test unset", map[string]interface{}{}, nil, 0},
+		{"Test port out of range", map[string]interface{}{ServicePortKey: 70000}, nil, 1},
+		{"Test port not a number", map[string]interface{}{ServicePortKey: "http"}, nil, 1},
+		{"Test invalid level and timeout", map[string]interface{}{LogLevelKey: "VERBOSE", ServiceReadTimeoutKey: -1}, nil, 2},
+		{"Test selected keys", map[string]interface{}{ServicePortKey: 70000, LogLevelKey: "VERBOSE"}, []string{ServicePortKey, "unknown.key"}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+			defer viper.Reset()
+
+			for k, v := range tt.settings {
+				viper.Set(k, v)
+			}
+
+			err := Validate()
+
+			if tt.keys != nil {
+				err = ValidateKeys(tt.keys...)
+			}
+
+			if tt.problems == 0 {
+				if err != nil {
+					t.Errorf("Validate() error = %v, want nil", err)
+				}
+
+				return
+			}
+
+			verr, ok := err.(*ValidationError)
+
+			if !ok {
+				t.Fatalf("Validate() error = %v, want *ValidationError", err)
+			}
+
+			if len(verr.Problems) != tt.problems {
+				t.Errorf("Validate() problems = %v, want %d problems", verr.Problems, tt.problems)
+			}
+		})
+	}
+}
+
+func TestWriteDefaults(t *testing.T) {
+	var b bytes.Buffer
+
+	if err := WriteDefaults(&b); err != nil {
+		t.Fatalf("WriteDefaults() error = %v", err)
+	}
+
+	viper.Reset()
+	defer viper.Reset()
+
+	viper.SetConfigType("yaml")
+
+	if err := viper.ReadConfig(&b); err != nil {
+		t.Fatalf("could not read the default configuration: %v", err)
+	}
+
+	for _, k := range Keys() {
+		if !viper.IsSet(k.Name) {
+			t.Errorf("%s is missing from the default configuration", k.Name)
+		}
+	}
+
+	if err := Validate(); err != nil {
+		t.Errorf("Validate() error = %v, the default configuration should be valid", err)
+	}
+
+	if unknown := UnknownKeys(); len(unknown) > 0 {
+		t.Errorf("UnknownKeys() = %v, want none", unknown)
+	}
+}
+
+func TestSampleConfiguration(t *testing.T) {
+	src := NewSource()
+
+	if err := src.Load(Layers{File: "../conf/application.yaml"}); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if unknown := src.UnknownKeys(); len(unknown) != 0 {
+		t.Errorf("UnknownKeys() = %v, want the sample configuration to only use registered keys", unknown)
+	}
+
+	if err := src.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
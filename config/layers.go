@@ -0,0 +1,312 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// ProfileEnv is the environment variable used to select the active configuration profiles
+	// when they have not been provided on the command line, e.g. APP_PROFILE=dev,local
+	ProfileEnv = "APP_PROFILE"
+	// DefaultConfigName is the name, without extension, of the base configuration file
+	DefaultConfigName = "application"
+	// DefaultFragmentDir is the directory, relative to the base configuration file, that holds configuration fragments
+	DefaultFragmentDir = "conf.d"
+)
+
+var envKeyReplacer = strings.NewReplacer(".", "_", "-", "_")
+
+// Layers describes the configuration files that are merged together to form the application configuration.
+// The base file is read first, then the file for each active profile, e.g. application-dev.yaml, in the
+// order given, and finally the fragments found in the fragment directory in lexical order. Later layers
+// override the values supplied by earlier layers, and environment variables override them all.
+type Layers struct {
+	// File is the path to the base configuration file, if it is empty the base file is searched for in Paths
+	File string
+	// Name is the name of the base configuration file without its extension
+	Name string
+	// Paths are the directories searched for the base configuration file
+	Paths []string
+	// Profiles are the active configuration profiles
+	Profiles []string
+	// FragmentDir is the directory containing configuration fragments, relative paths are resolved
+	// against the directory of the base configuration file
+	FragmentDir string
+}
+
+// Profiles splits a comma separated list of profile names, ignoring empty entries,
+// if the list is empty, the profiles are read from the APP_PROFILE environment variable
+func Profiles(profiles ...string) []string {
+	if len(profiles) == 0 {
+		profiles = []string{os.Getenv(ProfileEnv)}
+	}
+
+	var result []string
+
+	for _, p := range profiles {
+		for _, s := range strings.Split(p, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				result = append(result, s)
+			}
+		}
+	}
+
+	return result
+}
+
+// EnvName returns the name of the environment variable that overrides the given configuration key
+func EnvName(key string) string {
+	return strings.ToUpper(envKeyReplacer.Replace(key))
+}
+
+// Load reads the configuration described by the layers into the global source, see Source.Load
+func Load(l Layers) error {
+	return Global().Load(l)
+}
+
+// Load reads the base configuration file and merges the profile files and fragments described by
+// the given layers on top of it. Profile files that do not exist are skipped. Secret references
+// such as ${file:/run/secrets/db} are resolved as each file is read. The source is locked while
+// it is loaded, so that it can be reloaded while the service reads it.
+func (s *Source) Load(l Layers) (err error) {
+	s.write(func(v *viper.Viper) {
+		err = s.load(v, l)
+	})
+
+	return err
+}
+
+func (s *Source) load(v *viper.Viper, l Layers) error {
+	if l.Name == "" {
+		l.Name = DefaultConfigName
+	}
+
+	v.SetEnvKeyReplacer(envKeyReplacer)
+	v.AutomaticEnv()
+
+	if l.File != "" {
+		v.SetConfigFile(l.File)
+	} else if v.ConfigFileUsed() == "" {
+		// once found, the base file is read again when the configuration is reloaded
+		for _, p := range l.Paths {
+			v.AddConfigPath(p)
+		}
+
+		v.SetConfigName(l.Name)
+	}
+
+	if err := v.ReadInConfig(); err != nil {
+		return err
+	}
+
+	p := newProvenance()
+	base := v.ConfigFileUsed()
+
+	files, err := l.files(base)
+
+	if err != nil {
+		return err
+	}
+
+	// the base file is read again so that its secret references are resolved and
+	// the resolved values merged over the raw values read by viper
+	for _, f := range append([]string{base}, files...) {
+		settings, err := p.readLayer(f)
+
+		if err != nil {
+			return err
+		}
+
+		if err := v.MergeConfigMap(settings); err != nil {
+			return fmt.Errorf("could not merge configuration file %s: %w", f, err)
+		}
+	}
+
+	p.recordEnv(v.AllKeys())
+
+	s.setSources(p)
+
+	return nil
+}
+
+// files returns the profile files and fragments to be merged on top of the base configuration file
+func (l Layers) files(base string) ([]string, error) {
+	dir := filepath.Dir(base)
+	ext := filepath.Ext(base)
+	name := strings.TrimSuffix(filepath.Base(base), ext)
+
+	var files []string
+
+	for _, profile := range l.Profiles {
+		f := filepath.Join(dir, fmt.Sprintf("%s-%s%s", name, profile, ext))
+
+		if _, err := os.Stat(f); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		files = append(files, f)
+	}
+
+	if l.FragmentDir == "" {
+		return files, nil
+	}
+
+	fragmentDir := l.FragmentDir
+
+	if !filepath.IsAbs(fragmentDir) {
+		fragmentDir = filepath.Join(dir, fragmentDir)
+	}
+
+	entries, err := ioutil.ReadDir(fragmentDir)
+
+	if err != nil {
+		if os.IsNotExist(err) {
+			return files, nil
+		}
+
+		return nil, err
+	}
+
+	// ioutil.ReadDir returns the entries sorted by file name
+	for _, e := range entries {
+		if e.IsDir() || !supportedExt(e.Name()) {
+			continue
+		}
+
+		files = append(files, filepath.Join(fragmentDir, e.Name()))
+	}
+
+	return files, nil
+}
+
+func supportedExt(name string) bool {
+	ext := strings.TrimPrefix(filepath.Ext(name), ".")
+
+	for _, e := range viper.SupportedExts {
+		if ext == e {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Sources returns the provenance of the configuration values read into the global source by the last call to Load
+func Sources() *Provenance {
+	return Global().Sources()
+}
+
+// Provenance records the configuration file or environment variable that supplied each configuration value
+type Provenance struct {
+	files   []string
+	sources map[string]string
+	secrets map[string]bool
+}
+
+func newProvenance() *Provenance {
+	return &Provenance{
+		sources: make(map[string]string),
+		secrets: make(map[string]bool),
+	}
+}
+
+// Files returns the configuration files that were read, in the order they were merged
+func (p *Provenance) Files() []string {
+	return p.files
+}
+
+// Source returns the file or environment variable that supplied the value for the given key,
+// an empty string is returned if the key has not been set
+func (p *Provenance) Source(key string) string {
+	return p.sources[strings.ToLower(key)]
+}
+
+// Keys returns the sorted list of keys with a known source
+func (p *Provenance) Keys() []string {
+	keys := make([]string, 0, len(p.sources))
+
+	for k := range p.sources {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// WriteReport writes a table of each configuration key and the source that supplied its value
+func (p *Provenance) WriteReport(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	if _, err := fmt.Fprintln(tw, "KEY\tSOURCE"); err != nil {
+		return err
+	}
+
+	for _, k := range p.Keys() {
+		if _, err := fmt.Fprintf(tw, "%s\t%s\n", k, p.sources[k]); err != nil {
+			return err
+		}
+	}
+
+	return tw.Flush()
+}
+
+// readLayer reads a single configuration file, records it as the source for each of the keys
+// it contains and returns its settings, with any secret references resolved, so they can be
+// merged into the application configuration
+func (p *Provenance) readLayer(file string) (map[string]interface{}, error) {
+	v := viper.New()
+	v.SetConfigFile(file)
+
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("could not read configuration file %s: %w", file, err)
+	}
+
+	for _, k := range v.AllKeys() {
+		p.sources[k] = file
+		delete(p.secrets, k)
+	}
+
+	settings := v.AllSettings()
+
+	if err := resolveSettings("", settings, p.secrets); err != nil {
+		return nil, fmt.Errorf("could not resolve secrets in configuration file %s: %w", file, err)
+	}
+
+	p.files = append(p.files, file)
+
+	return settings, nil
+}
+
+// recordEnv records the environment variable as the source of each of the given keys, and of each
+// registered key, that has been overridden by the environment, whether or not the key is set by a file
+func (p *Provenance) recordEnv(keys []string) {
+	for _, k := range keys {
+		p.recordEnvKey(k)
+	}
+
+	for _, k := range Keys() {
+		p.recordEnvKey(k.Name)
+	}
+}
+
+func (p *Provenance) recordEnvKey(key string) {
+	name := EnvName(key)
+
+	if _, ok := os.LookupEnv(name); ok {
+		p.sources[key] = "env:" + name
+	}
+}
@@ -0,0 +1,138 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	writeFiles(t, dir, map[string]string{
+		"application.yaml":       "service:\n  host: localhost\n  port: 8080\n  name: base\nlog:\n  level: INFO\n",
+		"application-dev.yaml":   "service:\n  port: 8081\nlog:\n  level: DEBUG\n",
+		"application-local.yaml": "service:\n  port: 8082\n",
+		"conf.d/20-name.yaml":    "service:\n  name: fragment-20\n",
+		"conf.d/10-name.yaml":    "service:\n  name: fragment-10\n  write-timeout-seconds: 5\n",
+		"conf.d/ignored.txt":     "service: ignored\n",
+	})
+
+	if err := os.Setenv("LOG_LEVEL", "WARN"); err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.Unsetenv("LOG_LEVEL")
+
+	// a key that is not set by any of the files, with its '.' and '-' replaced by '_'
+	if err := os.Setenv("SERVICE_READ_TIMEOUT_SECONDS", "7"); err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.Unsetenv("SERVICE_READ_TIMEOUT_SECONDS")
+
+	viper.Reset()
+	defer viper.Reset()
+
+	err = Load(Layers{
+		File:        filepath.Join(dir, "application.yaml"),
+		Profiles:    []string{"dev", "missing", "local"},
+		FragmentDir: DefaultFragmentDir,
+	})
+
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	values := []struct {
+		key  string
+		want interface{}
+		src  string
+	}{
+		{ServiceHostKey, "localhost", filepath.Join(dir, "application.yaml")},
+		{ServicePortKey, 8082, filepath.Join(dir, "application-local.yaml")},
+		{ServiceNameKey, "fragment-20", filepath.Join(dir, "conf.d", "20-name.yaml")},
+		{ServiceWriteTimeoutKey, 5, filepath.Join(dir, "conf.d", "10-name.yaml")},
+		{LogLevelKey, "WARN", "env:LOG_LEVEL"},
+		{ServiceReadTimeoutKey, "7", "env:SERVICE_READ_TIMEOUT_SECONDS"},
+	}
+
+	for _, tt := range values {
+		t.Run(tt.key, func(t *testing.T) {
+			if got := Get(tt.key).Value(nil); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Get(%s) = %v, want %v", tt.key, got, tt.want)
+			}
+
+			if got := Sources().Source(tt.key); got != tt.src {
+				t.Errorf("Source(%s) = %v, want %v", tt.key, got, tt.src)
+			}
+		})
+	}
+
+	wantFiles := []string{
+		filepath.Join(dir, "application.yaml"),
+		filepath.Join(dir, "application-dev.yaml"),
+		filepath.Join(dir, "application-local.yaml"),
+		filepath.Join(dir, "conf.d", "10-name.yaml"),
+		filepath.Join(dir, "conf.d", "20-name.yaml"),
+	}
+
+	if got := Sources().Files(); !reflect.DeepEqual(got, wantFiles) {
+		t.Errorf("Files() = %v, want %v", got, wantFiles)
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      string
+		profiles []string
+		want     []string
+	}{
+		{"Test flag", "", []string{"dev", "local"}, []string{"dev", "local"}},
+		{"Test comma separated flag", "", []string{"dev, local"}, []string{"dev", "local"}},
+		{"Test environment", "dev,,local", nil, []string{"dev", "local"}},
+		{"Test flag overrides environment", "prod", []string{"dev"}, []string{"dev"}},
+		{"Test no profiles", "", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.Setenv(ProfileEnv, tt.env); err != nil {
+				t.Fatal(err)
+			}
+
+			defer os.Unsetenv(ProfileEnv)
+
+			if got := Profiles(tt.profiles...); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Profiles() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
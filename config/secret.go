@@ -0,0 +1,272 @@
+package config
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// RedactedValue replaces the value of secret configuration whenever the configuration is logged or dumped
+const RedactedValue = "******"
+
+// secretRef matches references such as ${file:/run/secrets/db}, and references escaped as $${file:...}, which
+// are written literally as ${file:...}
+var secretRef = regexp.MustCompile(`(\$?)\$\{([a-zA-Z][a-zA-Z0-9_-]*):([^}]*)}`)
+
+// shellOperators start the expansions of a shell, such as ${HOME:-/tmp}, which are not secret references
+const shellOperators = "-=?+"
+
+// sensitiveKeys are fragments of configuration key names whose values are always redacted,
+// even when they have not been supplied through a secret reference
+var sensitiveKeys = []string{"password", "passwd", "secret", "token", "credential", "private-key", "api-key", "apikey"}
+
+var resolverLock sync.RWMutex
+var resolvers = map[string]Resolver{
+	"file":   ResolverFunc(resolveFile),
+	"env":    ResolverFunc(resolveEnv),
+	"base64": ResolverFunc(resolveBase64),
+}
+
+// Resolver resolves the reference of a secret, e.g. for ${file:/run/secrets/db} the file Resolver
+// is given the reference /run/secrets/db and returns the contents of that file
+type Resolver interface {
+	Resolve(ref string) (string, error)
+}
+
+// ResolverFunc allows an ordinary function to be used as a Resolver
+type ResolverFunc func(ref string) (string, error)
+
+// Resolve calls f(ref)
+func (f ResolverFunc) Resolve(ref string) (string, error) {
+	return f(ref)
+}
+
+// MapResolver resolves references from a fixed set of values, it can be used to fake a secret store
+// such as vault when running locally or in tests
+type MapResolver map[string]string
+
+// Resolve returns the value stored for the reference
+func (m MapResolver) Resolve(ref string) (string, error) {
+	if v, ok := m[ref]; ok {
+		return v, nil
+	}
+
+	return "", fmt.Errorf("secret %s not found", ref)
+}
+
+// RegisterResolver registers the resolver used for secret references with the given scheme, e.g. registering
+// a resolver for the scheme "vault" resolves references such as ${vault:database/password}.
+// Resolvers must be registered before the configuration is loaded.
+func RegisterResolver(scheme string, r Resolver) {
+	resolverLock.Lock()
+	defer resolverLock.Unlock()
+
+	resolvers[strings.ToLower(scheme)] = r
+}
+
+func resolver(scheme string) (Resolver, bool) {
+	resolverLock.RLock()
+	defer resolverLock.RUnlock()
+
+	r, ok := resolvers[strings.ToLower(scheme)]
+
+	return r, ok
+}
+
+func resolveFile(ref string) (string, error) {
+	b, err := ioutil.ReadFile(ref)
+
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+func resolveEnv(ref string) (string, error) {
+	if v, ok := os.LookupEnv(ref); ok {
+		return v, nil
+	}
+
+	return "", fmt.Errorf("environment variable %s is not set", ref)
+}
+
+func resolveBase64(ref string) (string, error) {
+	b, err := base64.StdEncoding.DecodeString(ref)
+
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+// resolveString replaces every secret reference in s with its resolved value, and reports whether s
+// contained any secret references. A reference whose scheme has no resolver is an error, so that a mistyped
+// scheme is not used as the value, escaped references and shell expansions are left unchanged.
+func resolveString(s string) (string, bool, error) {
+	var resolveErr error
+
+	found := false
+
+	resolved := secretRef.ReplaceAllStringFunc(s, func(ref string) string {
+		m := secretRef.FindStringSubmatch(ref)
+
+		if m[1] != "" {
+			return ref[1:]
+		}
+
+		if m[3] != "" && strings.ContainsRune(shellOperators, rune(m[3][0])) {
+			return ref
+		}
+
+		found = true
+
+		r, ok := resolver(m[2])
+
+		if !ok {
+			if resolveErr == nil {
+				resolveErr = fmt.Errorf("no resolver registered for secret reference %s, escape it as $%s to use it literally", ref, ref)
+			}
+
+			return ref
+		}
+
+		v, err := r.Resolve(m[3])
+
+		if err != nil && resolveErr == nil {
+			resolveErr = fmt.Errorf("could not resolve secret reference %s: %w", ref, err)
+		}
+
+		return v
+	})
+
+	return resolved, found, resolveErr
+}
+
+// resolveSettings replaces the secret references found in the string values of the settings in place,
+// recording the key of each value that contained a secret reference
+func resolveSettings(prefix string, settings map[string]interface{}, secrets map[string]bool) error {
+	for k, v := range settings {
+		key := joinKey(prefix, k)
+
+		resolved, err := resolveValue(key, v, secrets)
+
+		if err != nil {
+			return err
+		}
+
+		settings[k] = resolved
+	}
+
+	return nil
+}
+
+func resolveValue(key string, v interface{}, secrets map[string]bool) (interface{}, error) {
+	switch value := v.(type) {
+	case string:
+		resolved, found, err := resolveString(value)
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+
+		if found {
+			secrets[key] = true
+		}
+
+		return resolved, nil
+	case map[string]interface{}:
+		return value, resolveSettings(key, value, secrets)
+	case []interface{}:
+		for i, item := range value {
+			resolved, err := resolveValue(key, item, secrets)
+
+			if err != nil {
+				return nil, err
+			}
+
+			value[i] = resolved
+		}
+
+		return value, nil
+	default:
+		return v, nil
+	}
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return strings.ToLower(key)
+	}
+
+	return prefix + "." + strings.ToLower(key)
+}
+
+// IsSecret reports whether the key holds a secret in the global source, see Source.IsSecret
+func IsSecret(key string) bool {
+	return Global().IsSecret(key)
+}
+
+// IsSecret reports whether the value of the given key was supplied by a secret reference, or whether the
+// key name indicates that it holds sensitive information such as a password or token
+func (s *Source) IsSecret(key string) bool {
+	key = strings.ToLower(key)
+
+	if s.Sources().secrets[key] {
+		return true
+	}
+
+	for _, k := range sensitiveKeys {
+		if strings.Contains(key, k) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Redact returns RedactedValue in place of the value if the key holds a secret in the global source,
+// see Source.Redact
+func Redact(key string, value interface{}) interface{} {
+	return Global().Redact(key, value)
+}
+
+// Redact returns RedactedValue in place of the value if the key holds a secret, otherwise the value is
+// returned unchanged
+func (s *Source) Redact(key string, value interface{}) interface{} {
+	if s.IsSecret(key) {
+		return RedactedValue
+	}
+
+	if m, ok := value.(map[string]interface{}); ok {
+		return s.redactSettings(key, m)
+	}
+
+	return value
+}
+
+// Redacted returns all of the settings of the global source with the secret values redacted
+func Redacted() map[string]interface{} {
+	return Global().Redacted()
+}
+
+// Redacted returns all of the configuration settings with the secret values redacted
+// so that they can be safely logged or dumped
+func (s *Source) Redacted() map[string]interface{} {
+	return s.redactSettings("", s.AllSettings())
+}
+
+func (s *Source) redactSettings(prefix string, settings map[string]interface{}) map[string]interface{} {
+	redacted := make(map[string]interface{}, len(settings))
+
+	for k, v := range settings {
+		redacted[k] = s.Redact(joinKey(prefix, k), v)
+	}
+
+	return redacted
+}
@@ -0,0 +1,220 @@
+package config
+
+import (
+	"sync"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// Source is a configuration read from the configuration files and environment variables. Each source
+// has its own viper instance, so that services and libraries built on the bootstrap do not share their
+// settings and tests can run in parallel with different configurations. The package level functions
+// use the global source, backed by the global viper instance.
+//
+// A source can be read while it is reloaded, the accessors of the source take its read lock and Load takes
+// its write lock. The viper instance is not safe for concurrent use, calling its methods directly, or the
+// package level functions of viper for the global source, while the configuration is reloaded is a data race.
+type Source struct {
+	*viper.Viper
+	global bool
+
+	lock    sync.RWMutex
+	sources *Provenance
+}
+
+// globalSources holds the lock and the provenance of the values read into the global viper instance
+var globalSources struct {
+	lock    sync.RWMutex
+	sources *Provenance
+}
+
+func init() {
+	globalSources.sources = newProvenance()
+}
+
+// Global returns the source backed by the global viper instance, it is the source used when
+// another has not been given
+func Global() *Source {
+	return &Source{Viper: viper.GetViper(), global: true}
+}
+
+// NewSource creates a source with its own viper instance
+func NewSource() *Source {
+	return &Source{Viper: viper.New(), sources: newProvenance()}
+}
+
+// Config returns the typed accessor of the value at the given path, e.g. Config("service", "port").Int(9900)
+func (s *Source) Config(path ...string) *Config {
+	return &Config{path: path, source: s}
+}
+
+// Sources returns the provenance of the configuration values read by the last call to Load
+func (s *Source) Sources() *Provenance {
+	l := s.mutex()
+	l.RLock()
+	defer l.RUnlock()
+
+	if s.global {
+		return globalSources.sources
+	}
+
+	return s.sources
+}
+
+// setSources sets the provenance of the configuration values, the write lock of the source must be held
+func (s *Source) setSources(p *Provenance) {
+	if s.global {
+		globalSources.sources = p
+		return
+	}
+
+	s.sources = p
+}
+
+// mutex returns the lock guarding the viper instance and the provenance of the source, the sources backed
+// by the global viper instance share the same lock
+func (s *Source) mutex() *sync.RWMutex {
+	if s.global {
+		return &globalSources.lock
+	}
+
+	return &s.lock
+}
+
+// read calls fn with the read lock of the source held
+func (s *Source) read(fn func(v *viper.Viper)) {
+	l := s.mutex()
+	l.RLock()
+	defer l.RUnlock()
+
+	fn(s.Viper)
+}
+
+// write calls fn with the write lock of the source held
+func (s *Source) write(fn func(v *viper.Viper)) {
+	l := s.mutex()
+	l.Lock()
+	defer l.Unlock()
+
+	fn(s.Viper)
+}
+
+// Get returns the value of the key, see viper.Get
+func (s *Source) Get(key string) (value interface{}) {
+	s.read(func(v *viper.Viper) { value = v.Get(key) })
+	return value
+}
+
+// GetString returns the value of the key as a string
+func (s *Source) GetString(key string) (value string) {
+	s.read(func(v *viper.Viper) { value = v.GetString(key) })
+	return value
+}
+
+// GetBool returns the value of the key as a bool
+func (s *Source) GetBool(key string) (value bool) {
+	s.read(func(v *viper.Viper) { value = v.GetBool(key) })
+	return value
+}
+
+// GetInt returns the value of the key as an int
+func (s *Source) GetInt(key string) (value int) {
+	s.read(func(v *viper.Viper) { value = v.GetInt(key) })
+	return value
+}
+
+// GetInt32 returns the value of the key as an int32
+func (s *Source) GetInt32(key string) (value int32) {
+	s.read(func(v *viper.Viper) { value = v.GetInt32(key) })
+	return value
+}
+
+// GetInt64 returns the value of the key as an int64
+func (s *Source) GetInt64(key string) (value int64) {
+	s.read(func(v *viper.Viper) { value = v.GetInt64(key) })
+	return value
+}
+
+// GetFloat64 returns the value of the key as a float64
+func (s *Source) GetFloat64(key string) (value float64) {
+	s.read(func(v *viper.Viper) { value = v.GetFloat64(key) })
+	return value
+}
+
+// GetDuration returns the value of the key as a duration
+func (s *Source) GetDuration(key string) (value time.Duration) {
+	s.read(func(v *viper.Viper) { value = v.GetDuration(key) })
+	return value
+}
+
+// GetStringSlice returns the value of the key as a slice of strings
+func (s *Source) GetStringSlice(key string) (value []string) {
+	s.read(func(v *viper.Viper) { value = v.GetStringSlice(key) })
+	return value
+}
+
+// GetStringMap returns the value of the key as a map of interfaces
+func (s *Source) GetStringMap(key string) (value map[string]interface{}) {
+	s.read(func(v *viper.Viper) { value = v.GetStringMap(key) })
+	return value
+}
+
+// GetStringMapString returns the value of the key as a map of strings
+func (s *Source) GetStringMapString(key string) (value map[string]string) {
+	s.read(func(v *viper.Viper) { value = v.GetStringMapString(key) })
+	return value
+}
+
+// IsSet returns whether the key has been given a value
+func (s *Source) IsSet(key string) (set bool) {
+	s.read(func(v *viper.Viper) { set = v.IsSet(key) })
+	return set
+}
+
+// AllKeys returns all the keys that have been given a value
+func (s *Source) AllKeys() (keys []string) {
+	s.read(func(v *viper.Viper) { keys = v.AllKeys() })
+	return keys
+}
+
+// AllSettings returns the settings of the source as a nested map
+func (s *Source) AllSettings() (settings map[string]interface{}) {
+	s.read(func(v *viper.Viper) { settings = v.AllSettings() })
+	return settings
+}
+
+// ConfigFileUsed returns the base configuration file read by the last call to Load
+func (s *Source) ConfigFileUsed() (file string) {
+	s.read(func(v *viper.Viper) { file = v.ConfigFileUsed() })
+	return file
+}
+
+// UnmarshalKey decodes the value of the key into a struct, see viper.UnmarshalKey
+func (s *Source) UnmarshalKey(key string, rawVal interface{}, opts ...viper.DecoderConfigOption) (err error) {
+	s.read(func(v *viper.Viper) { err = v.UnmarshalKey(key, rawVal, opts...) })
+	return err
+}
+
+// Unmarshal decodes the settings of the source into a struct, see viper.Unmarshal
+func (s *Source) Unmarshal(rawVal interface{}, opts ...viper.DecoderConfigOption) (err error) {
+	s.read(func(v *viper.Viper) { err = v.Unmarshal(rawVal, opts...) })
+	return err
+}
+
+// Set overrides the value of the key, see viper.Set
+func (s *Source) Set(key string, value interface{}) {
+	s.write(func(v *viper.Viper) { v.Set(key, value) })
+}
+
+// SetDefault sets the value of the key used when it has not been given a value, see viper.SetDefault
+func (s *Source) SetDefault(key string, value interface{}) {
+	s.write(func(v *viper.Viper) { v.SetDefault(key, value) })
+}
+
+// BindPFlag binds the key to a flag, the value of the flag overrides the configuration when it has been given
+func (s *Source) BindPFlag(key string, flag *pflag.Flag) (err error) {
+	s.write(func(v *viper.Viper) { err = v.BindPFlag(key, flag) })
+	return err
+}
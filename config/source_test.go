@@ -0,0 +1,70 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestNewSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	writeFiles(t, dir, map[string]string{
+		"a/application.yaml": "service:\n  port: 8001\n  dsn: ${file:" + filepath.Join(dir, "secret") + "}\n",
+		"b/application.yaml": "service:\n  port: 8002\n",
+		"secret":             "s3cret",
+	})
+
+	viper.Reset()
+	defer viper.Reset()
+
+	a, b := NewSource(), NewSource()
+
+	if err := a.Load(Layers{File: filepath.Join(dir, "a", "application.yaml")}); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if err := b.Load(Layers{File: filepath.Join(dir, "b", "application.yaml")}); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		source *Source
+		port   int
+		file   string
+	}{
+		{"Test first source", a, 8001, filepath.Join(dir, "a", "application.yaml")},
+		{"Test second source", b, 8002, filepath.Join(dir, "b", "application.yaml")},
+		{"Test global source", Global(), 9900, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.source.Config("service", "port").Int(9900); got != tt.port {
+				t.Errorf("service.port = %d, want %d", got, tt.port)
+			}
+
+			if got := tt.source.Sources().Source(ServicePortKey); got != tt.file {
+				t.Errorf("Source(%s) = %q, want %q", ServicePortKey, got, tt.file)
+			}
+		})
+	}
+
+	if got := a.Redacted()["service"].(map[string]interface{})["dsn"]; got != RedactedValue {
+		t.Errorf("redacted dsn = %v, want %s", got, RedactedValue)
+	}
+
+	if b.IsSecret("service.dsn") || Global().IsSecret("service.dsn") {
+		t.Error("the secrets of one source are reported by another")
+	}
+}
@@ -3,8 +3,6 @@ package main
 import (
 	"net/http"
 
-	"github.com/natefinch/lumberjack"
-
 	"github.com/birchwood-langham/web-service-bootstrap/api"
 	"github.com/birchwood-langham/web-service-bootstrap/cmd"
 	"github.com/birchwood-langham/web-service-bootstrap/logger"
@@ -14,20 +12,25 @@ import (
 )
 
 type MyApp struct {
-	logFile *lumberjack.Logger
-	log     *zap.Logger
+	log *zap.Logger
+}
+
+// NewMyApp creates the application, the logger is created here rather than in Init as the routes command
+// registers the routes without initializing the application
+func NewMyApp() *MyApp {
+	// the named logger writes to the sinks configured in application.yaml, at the level
+	// configured under log.levels.myapp, or log.level if it has not been configured
+	return &MyApp{log: logger.Named("myapp").With(zap.String("app", "MyApp"))}
 }
 
 // Init performs any initialization that is required for my application
 func (a *MyApp) Init() (err error) {
-	a.logFile = logger.DefaultLumberjackLogger()
-	a.log = logger.New(logger.ApplicationLogLevel(), a.logFile).With(zap.String("app", "MyApp"))
 	a.log.Debug("Initializing MyApp")
 	return
 }
 
 // initialiseRoutes allows you to define the routes required for the service
-// and the handlers for each route
+// and the handlers for each route, they must not depend on Init
 func (a *MyApp) InitializeRoutes(s *api.Server) {
 	a.log.Debug("MyApp Initializing Routes")
 	s.Router.HandleFunc("/hello", a.hello).Methods("GET")
@@ -38,9 +41,8 @@ func (a *MyApp) InitializeRoutes(s *api.Server) {
 // the application, you would implement it here
 func (a *MyApp) Cleanup() error {
 	a.log.Debug("MyApp Cleaning up")
-	_ = a.log.Sync()
 
-	return a.logFile.Close()
+	return nil
 }
 
 func (a *MyApp) Properties() service.Properties {
@@ -48,11 +50,12 @@ func (a *MyApp) Properties() service.Properties {
 }
 
 // This is the obligatory hello world example implementing a Hello World service with this library
-func (a *MyApp) hello(w http.ResponseWriter, _ *http.Request) {
-	a.log.Info("Received a request to say hello", zap.String("context", "hello"), zap.Int("test", 10), zap.String("version", "0.1.0"))
+func (a *MyApp) hello(w http.ResponseWriter, r *http.Request) {
+	// the fields added to the context of the request, such as the request ID, are included in the entry
+	a.log.With(logger.Fields(r.Context())...).Info("Received a request to say hello", zap.String("context", "hello"), zap.Int("test", 10), zap.String("version", "0.1.0"))
 	api.RespondWithJSON(w, http.StatusOK, "Hello, World!")
 }
 
 func main() {
-	cmd.Execute(&MyApp{})
+	cmd.Execute(NewMyApp())
 }
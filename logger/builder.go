@@ -0,0 +1,191 @@
+package logger
+
+import (
+	"io"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Builder builds loggers that are independent of each other, each with its own cores and sinks
+type Builder struct {
+	level    zapcore.LevelEnabler
+	levels   *Levels
+	sinks    []SinkConfig
+	cores    []zapcore.Core
+	closers  []io.Closer
+	options  []zap.Option
+	redactor *Redactor
+	sampling SamplingConfig
+	dedup    time.Duration
+}
+
+// NewBuilder creates a builder for a logger that writes at the application log level, which can
+// be changed at runtime with SetLevel
+func NewBuilder() *Builder {
+	return &Builder{
+		options: []zap.Option{zap.AddCaller()},
+	}
+}
+
+// Level sets the level of the logger, replacing the log level of its levels
+func (b *Builder) Level(level zapcore.LevelEnabler) *Builder {
+	b.level = level
+	return b
+}
+
+// Levels sets the levels of the logger and of the loggers of its named components, replacing the levels of
+// the global logger
+func (b *Builder) Levels(levels *Levels) *Builder {
+	b.levels = levels
+	return b
+}
+
+// Sinks adds sinks the logger writes to
+func (b *Builder) Sinks(sinks ...SinkConfig) *Builder {
+	b.sinks = append(b.sinks, sinks...)
+	return b
+}
+
+// Writer adds a writer the logger writes to with the given encoder, the writer is not
+// closed when the logger is closed
+func (b *Builder) Writer(enc zapcore.Encoder, writer io.Writer) *Builder {
+	return b.Core(zapcore.NewCore(enc, zapcore.AddSync(writer), zapcore.DebugLevel))
+}
+
+// Core adds a core the logger writes to
+func (b *Builder) Core(core zapcore.Core) *Builder {
+	b.cores = append(b.cores, core)
+	return b
+}
+
+// Closer adds a closer that is closed when the logger is closed, for example the
+// destination of a core added with Core
+func (b *Builder) Closer(closer io.Closer) *Builder {
+	b.closers = append(b.closers, closer)
+	return b
+}
+
+// Redact redacts sensitive values from the entries written by the logger, a nil redactor disables redaction
+func (b *Builder) Redact(r *Redactor) *Builder {
+	b.redactor = r
+	return b
+}
+
+// Sample limits the number of entries with the same level and message written by the logger
+func (b *Builder) Sample(cfg SamplingConfig) *Builder {
+	b.sampling = cfg
+	return b
+}
+
+// Dedup collapses repeated messages written within the interval into a single "repeated N times" entry,
+// a zero interval disables it
+func (b *Builder) Dedup(interval time.Duration) *Builder {
+	b.dedup = interval
+	return b
+}
+
+// Options adds zap options to the logger
+func (b *Builder) Options(options ...zap.Option) *Builder {
+	b.options = append(b.options, options...)
+	return b
+}
+
+// Build creates the logger, if the logger has no sinks or cores it discards every entry
+func (b *Builder) Build() (*Logger, error) {
+	cores, closers, err := newSinkCores(b.sinks)
+
+	if err != nil {
+		return nil, err
+	}
+
+	cores = append(cores, b.cores...)
+
+	if b.redactor != nil {
+		// each core is redacted on its own, so that it only writes the entries allowed by its level
+		for i, c := range cores {
+			cores[i] = NewRedactCore(c, b.redactor)
+		}
+	}
+
+	// the root core accepts every level, loggers filter the entries they write to it by their own level,
+	// so that the application and component loggers can share the root core with different levels
+	root := zapcore.NewTee(cores...)
+
+	if b.sampling.enabled() {
+		root = NewSamplingCore(root, b.sampling)
+	}
+
+	if b.dedup > 0 {
+		// the dedup core is closed first, so that its pending summaries are written before the sinks are closed
+		var dedup io.Closer
+		root, dedup = NewDedupCore(root, b.dedup)
+		closers = append([]io.Closer{dedup}, closers...)
+	}
+
+	levels := b.levels
+
+	if levels == nil {
+		levels = defaultLevels
+	}
+
+	level := b.level
+
+	if level == nil {
+		level = levels.level
+	}
+
+	return &Logger{
+		Logger:   zap.New(&levelFilterCore{Core: root, level: level}, b.options...),
+		root:     root,
+		options:  b.options,
+		closers:  append(closers, b.closers...),
+		redactor: b.redactor,
+		levels:   levels,
+	}, nil
+}
+
+// Logger is a zap logger that owns the sinks it writes to
+type Logger struct {
+	*zap.Logger
+	root       zapcore.Core
+	options    []zap.Option
+	closers    []io.Closer
+	redactor   *Redactor
+	levels     *Levels
+	ownsLevels bool
+}
+
+// Levels returns the levels of the logger, which change the level of the logger and of the loggers of its named
+// components at runtime
+func (l *Logger) Levels() *Levels {
+	return l.levels
+}
+
+// Named returns a logger for the named component, it writes to the sinks of the logger at the level of the
+// component in the levels of the logger, see the package level Named
+func (l *Logger) Named(name string) *zap.Logger {
+	return zap.New(&levelFilterCore{Core: l.root, level: l.levels.componentLevel(name)}, l.options...).Named(name)
+}
+
+// Close flushes any buffered log entries and closes the sinks of the logger,
+// the logger must not be used once it has been closed
+func (l *Logger) Close() error {
+	if l.ownsLevels {
+		// the pending reverts would log to the closed sinks
+		l.levels.stopReverts()
+	}
+
+	_ = l.Sync()
+
+	var err error
+
+	for _, c := range l.closers {
+		if cerr := c.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+
+	return err
+}
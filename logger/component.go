@@ -0,0 +1,297 @@
+package logger
+
+import (
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/birchwood-langham/web-service-bootstrap/config"
+)
+
+// componentLevel is the level of a named logger, it follows the log level of the logger
+// unless a level has been set for the component
+type componentLevel struct {
+	parent   zap.AtomicLevel
+	override zap.AtomicLevel
+	set      int32
+	timer    *time.Timer
+}
+
+// Enabled implements zapcore.LevelEnabler
+func (c *componentLevel) Enabled(l zapcore.Level) bool {
+	if atomic.LoadInt32(&c.set) == 1 {
+		return c.override.Enabled(l)
+	}
+
+	return c.parent.Enabled(l)
+}
+
+func (c *componentLevel) level() (zapcore.Level, bool) {
+	if atomic.LoadInt32(&c.set) == 1 {
+		return c.override.Level(), true
+	}
+
+	return c.parent.Level(), false
+}
+
+func (c *componentLevel) setLevel(level zapcore.Level) {
+	c.stopRevert()
+	c.override.SetLevel(level)
+	atomic.StoreInt32(&c.set, 1)
+}
+
+func (c *componentLevel) reset() {
+	c.stopRevert()
+	atomic.StoreInt32(&c.set, 0)
+}
+
+func (c *componentLevel) stopRevert() {
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+}
+
+// component returns the level of the named component, creating it if it does not exist,
+// the lock of the levels must be held by the caller
+func (lv *Levels) component(name string) *componentLevel {
+	name = strings.ToLower(name)
+
+	c, ok := lv.components[name]
+
+	if !ok {
+		c = &componentLevel{parent: lv.level, override: zap.NewAtomicLevel()}
+		lv.components[name] = c
+	}
+
+	return c
+}
+
+// componentLevel returns the level of the named component, creating it if it does not exist
+func (lv *Levels) componentLevel(name string) *componentLevel {
+	lv.lock.Lock()
+	defer lv.lock.Unlock()
+
+	return lv.component(name)
+}
+
+// levelFilterCore filters the entries written to the wrapped core by a level
+// that may be more restrictive than the level of the wrapped core
+type levelFilterCore struct {
+	zapcore.Core
+	level zapcore.LevelEnabler
+}
+
+func (c *levelFilterCore) Enabled(l zapcore.Level) bool {
+	return c.level.Enabled(l) && c.Core.Enabled(l)
+}
+
+func (c *levelFilterCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelFilterCore{Core: c.Core.With(fields), level: c.level}
+}
+
+func (c *levelFilterCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.level.Enabled(ent.Level) {
+		return ce
+	}
+
+	return c.Core.Check(ent, ce)
+}
+
+// Named returns a logger for the named component of the application, e.g. logger.Named("db"). Its level is
+// configured independently of the application log level under log.levels, e.g. log.levels.db: DEBUG,
+// and follows the application log level if it has not been configured.
+// Named loggers write to the sinks of the global logger, including after it has been reconfigured.
+func Named(name string) *zap.Logger {
+	return zap.New(&levelFilterCore{Core: &globalCore{}, level: defaultLevels.componentLevel(name)}, zap.AddCaller()).Named(name)
+}
+
+// SetComponentLevel sets the level of the named component, cancelling any pending revert
+func SetComponentLevel(name string, level zapcore.Level) {
+	defaultLevels.SetComponentLevel(name, level)
+}
+
+// SetComponentLevel sets the level of the named component, cancelling any pending revert
+func (lv *Levels) SetComponentLevel(name string, level zapcore.Level) {
+	lv.SetComponentLevelFor(name, level, 0)
+}
+
+// SetComponentLevelFor sets the level of the named component temporarily, after the duration has elapsed
+// the component reverts to following the application log level. If the duration is zero or negative the
+// level is not reverted.
+func SetComponentLevelFor(name string, level zapcore.Level, d time.Duration) {
+	defaultLevels.SetComponentLevelFor(name, level, d)
+}
+
+// SetComponentLevelFor sets the level of the named component temporarily, see the package level SetComponentLevelFor
+func (lv *Levels) SetComponentLevelFor(name string, level zapcore.Level, d time.Duration) {
+	lv.lock.Lock()
+	defer lv.lock.Unlock()
+
+	c := lv.component(name)
+	c.setLevel(level)
+
+	if d > 0 {
+		c.timer = time.AfterFunc(d, func() {
+			lv.ResetComponentLevel(name)
+			lv.sugar().Infof("Log level of %s reverted to the application log level", name)
+		})
+	}
+}
+
+// ResetComponentLevel removes the level set for the named component, so that it
+// follows the application log level
+func ResetComponentLevel(name string) {
+	defaultLevels.ResetComponentLevel(name)
+}
+
+// ResetComponentLevel removes the level set for the named component, so that it follows the log level
+func (lv *Levels) ResetComponentLevel(name string) {
+	lv.lock.Lock()
+	defer lv.lock.Unlock()
+
+	lv.component(name).reset()
+}
+
+// ComponentLevel describes the level of a named component
+type ComponentLevel struct {
+	Level string `json:"level"`
+	// Configured is true if the component has its own level rather than following the application log level
+	Configured bool `json:"configured"`
+}
+
+func (lv *Levels) componentLevelOf(name string) ComponentLevel {
+	lv.lock.Lock()
+	defer lv.lock.Unlock()
+
+	level, configured := lv.component(name).level()
+
+	return ComponentLevel{Level: level.CapitalString(), Configured: configured}
+}
+
+// ComponentLevels returns the level of each named component
+func ComponentLevels() map[string]ComponentLevel {
+	return defaultLevels.ComponentLevels()
+}
+
+// ComponentLevels returns the level of each named component
+func (lv *Levels) ComponentLevels() map[string]ComponentLevel {
+	lv.lock.Lock()
+	defer lv.lock.Unlock()
+
+	levels := make(map[string]ComponentLevel, len(lv.components))
+
+	for name, c := range lv.components {
+		level, configured := c.level()
+		levels[name] = ComponentLevel{Level: level.CapitalString(), Configured: configured}
+	}
+
+	return levels
+}
+
+// ApplyComponentLevels sets the level of each component configured under log.levels in the application
+// configuration file, components that are not configured revert to following the application log level
+func ApplyComponentLevels() error {
+	levels, err := componentLevelsFromSource(config.Global())
+
+	if err != nil {
+		return err
+	}
+
+	defaultLevels.lock.Lock()
+	defer defaultLevels.lock.Unlock()
+
+	defaultLevels.applyComponentLevels(levels)
+
+	return nil
+}
+
+// componentLevelsFromSource returns the levels of the components configured under log.levels in the source
+func componentLevelsFromSource(src *config.Source) (map[string]zapcore.Level, error) {
+	configured := src.GetStringMapString(config.LogLevelsKey)
+
+	levels := make(map[string]zapcore.Level, len(configured))
+
+	for name, l := range configured {
+		level, err := ParseLevel(l)
+
+		if err != nil {
+			return nil, err
+		}
+
+		levels[name] = level
+	}
+
+	return levels, nil
+}
+
+// applyComponentLevels sets the levels of the components, resetting the components that are not given a
+// level, the lock of the levels must be held by the caller
+func (lv *Levels) applyComponentLevels(levels map[string]zapcore.Level) {
+	for _, c := range lv.components {
+		c.reset()
+	}
+
+	for name, level := range levels {
+		lv.component(name).setLevel(level)
+	}
+}
+
+type componentLevelsPayload struct {
+	Level      string                    `json:"level"`
+	Components map[string]ComponentLevel `json:"components"`
+}
+
+// ComponentLevelsHandler returns an http.Handler that lists the application log level
+// and the level of each named component
+func ComponentLevelsHandler() http.Handler {
+	return defaultLevels.ComponentLevelsHandler()
+}
+
+// ComponentLevelsHandler returns an http.Handler that lists the log level and the level of each named component
+func (lv *Levels) ComponentLevelsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeLevel(w, http.StatusOK, componentLevelsPayload{
+			Level:      lv.level.Level().CapitalString(),
+			Components: lv.ComponentLevels(),
+		})
+	})
+}
+
+// ComponentLevelHandler returns an http.Handler that reports the level of the named component on GET,
+// changes it on PUT with a JSON body such as {"level": "debug", "revert_after": "10m"}, and on DELETE
+// resets it to follow the application log level
+func ComponentLevelHandler(name string) http.Handler {
+	return defaultLevels.ComponentLevelHandler(name)
+}
+
+// ComponentLevelHandler returns an http.Handler that reports and changes the level of the named component,
+// see the package level ComponentLevelHandler
+func (lv *Levels) ComponentLevelHandler(name string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			level, revertAfter, ok := lv.readLevelRequest(w, r)
+
+			if !ok {
+				return
+			}
+
+			lv.SetComponentLevelFor(name, level, revertAfter)
+			lv.sugar().Warnf("Log level of %s changed to %s", name, level.CapitalString())
+		case http.MethodDelete:
+			lv.ResetComponentLevel(name)
+			lv.sugar().Warnf("Log level of %s reset to the application log level", name)
+		default:
+			writeLevelError(w, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		writeLevel(w, http.StatusOK, lv.componentLevelOf(name))
+	})
+}
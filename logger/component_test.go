@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestNamed(t *testing.T) {
+	observed, logs := observer.New(zapcore.DebugLevel)
+
+	previous := loadRoot()
+	globalRoot.Store(&rootHolder{core: observed})
+
+	defer func() {
+		globalRoot.Store(previous)
+		ResetComponentLevel("db")
+		SetLevel(zapcore.InfoLevel)
+	}()
+
+	SetLevel(zapcore.WarnLevel)
+
+	db := Named("db")
+	http := Named("http")
+
+	tests := []struct {
+		name  string
+		setup func()
+		db    zapcore.Level
+		http  zapcore.Level
+		want  string
+	}{
+		{"Test follows application level", func() {}, zapcore.InfoLevel, zapcore.WarnLevel, "http"},
+		{"Test component level more verbose", func() { SetComponentLevel("db", zapcore.DebugLevel) }, zapcore.DebugLevel, zapcore.InfoLevel, "db"},
+		{"Test component level less verbose", func() { SetComponentLevel("DB", zapcore.ErrorLevel) }, zapcore.WarnLevel, zapcore.ErrorLevel, "http"},
+		{"Test reset component level", func() { ResetComponentLevel("db") }, zapcore.InfoLevel, zapcore.WarnLevel, "http"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup()
+			logs.TakeAll()
+
+			db.Check(tt.db, "db message").Write()
+			http.Check(tt.http, "http message").Write()
+
+			entries := logs.TakeAll()
+
+			if len(entries) != 1 {
+				t.Fatalf("wrote %d entries, want 1: %v", len(entries), entries)
+			}
+
+			if entries[0].LoggerName != tt.want {
+				t.Errorf("wrote entry for %s, want %s", entries[0].LoggerName, tt.want)
+			}
+		})
+	}
+}
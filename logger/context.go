@@ -0,0 +1,104 @@
+package logger
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type contextKey struct{}
+
+// contextLogger is the logger carried by a context and the fields that have been added to it
+type contextLogger struct {
+	logger *zap.Logger
+	fields []zap.Field
+}
+
+func loggerFrom(ctx context.Context) *contextLogger {
+	if ctx != nil {
+		if l, ok := ctx.Value(contextKey{}).(*contextLogger); ok {
+			return l
+		}
+	}
+
+	return nil
+}
+
+// WithContext returns a copy of the context carrying a logger with the fields added to the fields already
+// carried by the context, e.g. a middleware can add the request ID to the context of a request so that it
+// is included in every entry logged while the request is handled:
+//
+//	ctx := logger.WithContext(r.Context(), zap.String("request_id", id))
+//	next.ServeHTTP(w, r.WithContext(ctx))
+func WithContext(ctx context.Context, fields ...zap.Field) context.Context {
+	parent := loggerFrom(ctx)
+
+	l := &contextLogger{}
+
+	if parent != nil {
+		l.fields = append(l.fields, parent.fields...)
+	}
+
+	l.fields = append(l.fields, fields...)
+	l.logger = globalLogger().With(l.fields...)
+
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the logger carried by the context, or the global logger if the context does not carry
+// a logger. The logger writes to the sinks of the global logger, including after it has been reconfigured.
+func FromContext(ctx context.Context) *zap.Logger {
+	if l := loggerFrom(ctx); l != nil {
+		return l.logger
+	}
+
+	return globalLogger()
+}
+
+// SugarFromContext returns the sugared form of the logger carried by the context, or the global sugared
+// logger if the context does not carry a logger
+func SugarFromContext(ctx context.Context) *zap.SugaredLogger {
+	return FromContext(ctx).Sugar()
+}
+
+// Fields returns the fields carried by the context, for example to add them to a named logger:
+//
+//	logger.Named("db").With(logger.Fields(ctx)...)
+func Fields(ctx context.Context) []zap.Field {
+	if l := loggerFrom(ctx); l != nil {
+		return append([]zap.Field(nil), l.fields...)
+	}
+
+	return nil
+}
+
+// detachedContext carries the values of its parent but is never cancelled and has no deadline
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
+
+// Detach returns a context that carries the logger and other values of the context, but is not cancelled
+// when the context is cancelled. It is intended for goroutines that outlive the request that started them,
+// as the context of a request is cancelled once the handler returns:
+//
+//	go audit(logger.Detach(r.Context()), event)
+func Detach(ctx context.Context) context.Context {
+	return detachedContext{parent: ctx}
+}
@@ -0,0 +1,82 @@
+package logger
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestContext(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	l, err := NewBuilder().Core(core).Build()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer Override(l)()
+
+	parent, cancel := context.WithCancel(context.Background())
+	request := WithContext(parent, zap.String("request_id", "abc"))
+	user := WithContext(request, zap.String("user", "bob"))
+	detached := Detach(user)
+
+	cancel()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want map[string]interface{}
+	}{
+		{"Test context without logger", context.Background(), map[string]interface{}{}},
+		{"Test request fields", request, map[string]interface{}{"request_id": "abc"}},
+		{"Test fields are inherited", user, map[string]interface{}{"request_id": "abc", "user": "bob"}},
+		{"Test detached context", detached, map[string]interface{}{"request_id": "abc", "user": "bob"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			FromContext(tt.ctx).Info(tt.name)
+
+			entries := logs.TakeAll()
+
+			if len(entries) != 1 {
+				t.Fatalf("got %d entries, want 1", len(entries))
+			}
+
+			got := entries[0].ContextMap()
+
+			if len(got) != len(tt.want) {
+				t.Errorf("fields = %v, want %v", got, tt.want)
+			}
+
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("%s = %v, want %v", k, got[k], v)
+				}
+			}
+
+			if n := len(Fields(tt.ctx)); n != len(tt.want) {
+				t.Errorf("Fields returned %d fields, want %d", n, len(tt.want))
+			}
+		})
+	}
+
+	if user.Err() == nil {
+		t.Error("the parent context has not been cancelled")
+	}
+
+	select {
+	case <-detached.Done():
+		t.Error("the detached context has been cancelled")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	if detached.Err() != nil {
+		t.Errorf("detached context error = %v, want nil", detached.Err())
+	}
+}
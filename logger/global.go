@@ -0,0 +1,191 @@
+package logger
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/birchwood-langham/web-service-bootstrap/config"
+)
+
+var globalLock sync.Mutex
+var global *Logger
+
+// retired is the previous global logger, it is closed when the global logger is replaced again rather than
+// when it is replaced, as entries being written when it was replaced may still be writing to its sinks
+var retired *Logger
+
+// globalRoot holds the *rootHolder of the root core of the global logger
+var globalRoot atomic.Value
+
+type rootHolder struct {
+	core     zapcore.Core
+	redactor *Redactor
+}
+
+func init() {
+	globalRoot.Store(&rootHolder{core: zapcore.NewNopCore()})
+}
+
+func loadRoot() *rootHolder {
+	return globalRoot.Load().(*rootHolder)
+}
+
+// globalCore writes to the root core of the global logger at the time an entry is written, so that
+// loggers created before the global logger is reconfigured write to the sinks of the new global logger
+type globalCore struct {
+	fields  []zapcore.Field
+	derived atomic.Value
+}
+
+// derivedCore caches the root core with the fields of a globalCore added to it
+type derivedCore struct {
+	root *rootHolder
+	core zapcore.Core
+}
+
+func (c *globalCore) current() zapcore.Core {
+	root := loadRoot()
+
+	if d, ok := c.derived.Load().(*derivedCore); ok && d.root == root {
+		return d.core
+	}
+
+	core := root.core
+
+	if len(c.fields) > 0 {
+		core = core.With(c.fields)
+	}
+
+	c.derived.Store(&derivedCore{root: root, core: core})
+
+	return core
+}
+
+func (c *globalCore) Enabled(l zapcore.Level) bool {
+	return c.current().Enabled(l)
+}
+
+func (c *globalCore) With(fields []zapcore.Field) zapcore.Core {
+	combined := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	combined = append(combined, c.fields...)
+	combined = append(combined, fields...)
+
+	return &globalCore{fields: combined}
+}
+
+func (c *globalCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return c.current().Check(ent, ce)
+}
+
+func (c *globalCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.current().Write(ent, fields)
+}
+
+func (c *globalCore) Sync() error {
+	return c.current().Sync()
+}
+
+// globalLogger returns a logger at the application log level that writes to the global logger's sinks
+func globalLogger() *zap.Logger {
+	return zap.New(&levelFilterCore{Core: &globalCore{}, level: defaultLevels.level}, zap.AddCaller())
+}
+
+// ReplaceGlobals makes the logger the global logger used by zap.L(), zap.S() and the named component
+// loggers, replacing the previous global logger, which is flushed. The previous global logger is closed
+// when the global logger is replaced again, or closed by Close, so that the entries being written when it
+// was replaced do not write to closed sinks. Loggers obtained from the previous global logger write to the
+// new global logger's sinks.
+func ReplaceGlobals(l *Logger) {
+	previous, _ := swapGlobals(l)
+
+	if previous == nil || previous == l {
+		return
+	}
+
+	_ = previous.Sync()
+
+	globalLock.Lock()
+	closing := retired
+	retired = previous
+	globalLock.Unlock()
+
+	if closing != nil && closing != l {
+		_ = closing.Close()
+	}
+}
+
+// Override makes the logger the global logger until the returned function is called, which restores the
+// previous global logger. Unlike ReplaceGlobals neither logger is closed, it is intended for tests,
+// see the logtest package.
+func Override(l *Logger) (restore func()) {
+	previous, root := swapGlobals(l)
+
+	return func() {
+		globalLock.Lock()
+		defer globalLock.Unlock()
+
+		global = previous
+		globalRoot.Store(root)
+	}
+}
+
+// swapGlobals makes the logger the global logger, returning the previous global logger and its root
+func swapGlobals(l *Logger) (*Logger, *rootHolder) {
+	globalLock.Lock()
+	defer globalLock.Unlock()
+
+	previous, root := global, loadRoot()
+	global = l
+
+	globalRoot.Store(&rootHolder{core: l.root, redactor: l.redactor})
+	zap.ReplaceGlobals(globalLogger())
+
+	return previous, root
+}
+
+// Configure builds the global logger from the log level, format and sinks defined in the application
+// configuration file, replacing the previous global logger. It is called when the application starts
+// and whenever the configuration is reloaded. If the logger cannot be built the previous global
+// logger continues to be used.
+func Configure() error {
+	l, err := NewFromConfig()
+
+	if err != nil {
+		return err
+	}
+
+	ReplaceGlobals(l)
+
+	return defaultLevels.Configure(config.Global())
+}
+
+// Close flushes and closes the global logger and the logger it replaced, entries logged afterwards are
+// discarded
+func Close() error {
+	globalLock.Lock()
+
+	l, previous := global, retired
+	global, retired = nil, nil
+
+	globalRoot.Store(&rootHolder{core: zapcore.NewNopCore()})
+
+	globalLock.Unlock()
+
+	if previous != nil && previous != l {
+		_ = previous.Close()
+	}
+
+	if l == nil {
+		return nil
+	}
+
+	return l.Close()
+}
+
+// Sync flushes any buffered log entries of the global logger
+func Sync() error {
+	return loadRoot().core.Sync()
+}
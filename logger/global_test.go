@@ -0,0 +1,163 @@
+package logger
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type closeRecorder struct {
+	closed int
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed++
+	return nil
+}
+
+func TestReplaceGlobals(t *testing.T) {
+	previous := loadRoot()
+
+	defer func() {
+		globalRoot.Store(previous)
+		global, retired = nil, nil
+	}()
+
+	firstCore, first := observer.New(zapcore.DebugLevel)
+	firstCloser := &closeRecorder{}
+	firstLogger, err := NewBuilder().Core(firstCore).Closer(firstCloser).Build()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ReplaceGlobals(firstLogger)
+
+	named := Named("component").With(zap.String("key", "value"))
+	sugared := zap.S()
+
+	named.Info("first")
+	sugared.Info("first")
+
+	secondCore, second := observer.New(zapcore.DebugLevel)
+	secondLogger, err := NewBuilder().Core(secondCore).Build()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ReplaceGlobals(secondLogger)
+
+	named.Info("second")
+	sugared.Info("second")
+
+	// entries being written by the previous global logger may still be writing to its sinks
+	if firstCloser.closed != 0 {
+		t.Errorf("previous global logger closed %d times when replaced, want 0", firstCloser.closed)
+	}
+
+	third, err := NewBuilder().Core(zapcore.NewNopCore()).Build()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ReplaceGlobals(third)
+
+	if firstCloser.closed != 1 {
+		t.Errorf("previous global logger closed %d times when replaced again, want 1", firstCloser.closed)
+	}
+
+	for _, tt := range []struct {
+		name string
+		logs *observer.ObservedLogs
+		want string
+	}{
+		{"Test before replace", first, "first"},
+		{"Test after replace", second, "second"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := tt.logs.AllUntimed()
+
+			if len(entries) != 2 {
+				t.Fatalf("wrote %d entries, want 2: %v", len(entries), entries)
+			}
+
+			for _, e := range entries {
+				if e.Message != tt.want {
+					t.Errorf("wrote %s, want %s", e.Message, tt.want)
+				}
+			}
+
+			if fields := entries[0].ContextMap(); fields["key"] != "value" {
+				t.Errorf("named logger fields = %v, want key=value", fields)
+			}
+		})
+	}
+}
+
+func TestNewIsIndependent(t *testing.T) {
+	firstCore, first := observer.New(zapcore.DebugLevel)
+	secondCore, second := observer.New(zapcore.DebugLevel)
+
+	l1, _ := NewBuilder().Level(zapcore.InfoLevel).Core(firstCore).Build()
+	l2, _ := NewBuilder().Level(zapcore.DebugLevel).Core(secondCore).Build()
+
+	l1.Debug("debug")
+	l2.Debug("debug")
+
+	if first.Len() != 0 {
+		t.Errorf("first logger wrote %d entries, want 0", first.Len())
+	}
+
+	if second.Len() != 1 {
+		t.Errorf("second logger wrote %d entries, want 1", second.Len())
+	}
+}
+
+func TestLevelsAreIndependent(t *testing.T) {
+	defer func() {
+		ResetComponentLevel("db")
+		SetLevel(zapcore.InfoLevel)
+	}()
+
+	observed, logs := observer.New(zapcore.DebugLevel)
+	l, _ := NewBuilder().Levels(NewLevels()).Core(observed).Build()
+	db := l.Named("db")
+
+	tests := []struct {
+		name  string
+		setup func()
+		level zapcore.Level
+		db    zapcore.Level
+		want  int
+	}{
+		{"Test default levels do not apply", func() { SetLevel(zapcore.DebugLevel); SetComponentLevel("db", zapcore.DebugLevel) }, zapcore.DebugLevel, zapcore.DebugLevel, 0},
+		{"Test own level", func() { l.Levels().SetLevel(zapcore.DebugLevel) }, zapcore.DebugLevel, zapcore.InfoLevel, 2},
+		{"Test own component level", func() { l.Levels().SetComponentLevel("db", zapcore.ErrorLevel) }, zapcore.DebugLevel, zapcore.WarnLevel, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup()
+			logs.TakeAll()
+
+			l.Check(tt.level, "message").Write()
+			db.Check(tt.db, "db message").Write()
+
+			if entries := logs.TakeAll(); len(entries) != tt.want {
+				t.Errorf("wrote %d entries, want %d: %v", len(entries), tt.want, entries)
+			}
+		})
+	}
+
+	if level := Level().Level(); level != zapcore.DebugLevel {
+		t.Errorf("default level = %v, want debug", level)
+	}
+
+	if level := ComponentLevels()["db"].Level; level != "DEBUG" {
+		t.Errorf("default db level = %v, want DEBUG", level)
+	}
+}
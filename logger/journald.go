@@ -0,0 +1,225 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// DefaultJournaldSocket is the socket of the systemd journal
+const DefaultJournaldSocket = "/run/systemd/journal/socket"
+
+// journaldCore writes entries to the systemd journal using its native protocol, see
+// https://systemd.io/JOURNAL_NATIVE_PROTOCOL/. The structured fields of an entry are written as journal
+// fields, their names are converted to upper case with any character other than a letter, digit or
+// underscore replaced by an underscore, e.g. user.id is written as USER_ID.
+type journaldCore struct {
+	zapcore.LevelEnabler
+	context  *zapcore.MapObjectEncoder
+	writer   *journaldWriter
+	tag      string
+	facility int
+}
+
+func newJournaldCore(s SinkConfig, level zapcore.Level) (zapcore.Core, io.Closer, error) {
+	facility := -1
+
+	if s.Facility != "" {
+		var err error
+
+		if facility, err = parseFacility(s.Facility); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	address := s.Address
+
+	if address == "" {
+		address = DefaultJournaldSocket
+	}
+
+	w := &journaldWriter{address: address}
+
+	return &journaldCore{
+		LevelEnabler: level,
+		context:      zapcore.NewMapObjectEncoder(),
+		writer:       w,
+		tag:          appName(s.Tag),
+		facility:     facility,
+	}, w, nil
+}
+
+func (c *journaldCore) With(fields []zapcore.Field) zapcore.Core {
+	context := zapcore.NewMapObjectEncoder()
+
+	for k, v := range c.context.Fields {
+		context.Fields[k] = v
+	}
+
+	for _, f := range fields {
+		f.AddTo(context)
+	}
+
+	return &journaldCore{LevelEnabler: c.LevelEnabler, context: context, writer: c.writer, tag: c.tag, facility: c.facility}
+}
+
+func (c *journaldCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+
+	return ce
+}
+
+func (c *journaldCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+
+	for k, v := range c.context.Fields {
+		enc.Fields[k] = v
+	}
+
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+
+	var buf bytes.Buffer
+
+	appendJournalField(&buf, "MESSAGE", ent.Message)
+	appendJournalField(&buf, "PRIORITY", strconv.Itoa(syslogSeverity(ent.Level)))
+	appendJournalField(&buf, "SYSLOG_IDENTIFIER", c.tag)
+
+	if c.facility >= 0 {
+		appendJournalField(&buf, "SYSLOG_FACILITY", strconv.Itoa(c.facility))
+	}
+
+	if ent.LoggerName != "" {
+		appendJournalField(&buf, "LOGGER", ent.LoggerName)
+	}
+
+	if ent.Caller.Defined {
+		appendJournalField(&buf, "CODE_FILE", ent.Caller.File)
+		appendJournalField(&buf, "CODE_LINE", strconv.Itoa(ent.Caller.Line))
+
+		if fn := runtime.FuncForPC(ent.Caller.PC); fn != nil {
+			appendJournalField(&buf, "CODE_FUNC", fn.Name())
+		}
+	}
+
+	if ent.Stack != "" {
+		appendJournalField(&buf, "STACKTRACE", ent.Stack)
+	}
+
+	flattenFields("", enc.Fields, func(key, value string) {
+		appendJournalField(&buf, journalFieldName(key), value)
+	})
+
+	return c.writer.write(buf.Bytes())
+}
+
+func (c *journaldCore) Sync() error {
+	return nil
+}
+
+// journalFieldName converts a field name into a journal field name, which is made of upper case letters,
+// digits and underscores, does not start with an underscore or digit, and is at most 64 characters long
+func journalFieldName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, key)
+
+	// names starting with an underscore are reserved for fields added by the journal
+	name = strings.TrimLeft(name, "_")
+
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "FIELD_" + name
+	}
+
+	if len(name) > 64 {
+		name = name[:64]
+	}
+
+	return name
+}
+
+// appendJournalField appends a field in the native journal format, values containing a new line
+// are written as binary data prefixed by their length
+func appendJournalField(buf *bytes.Buffer, name, value string) {
+	buf.WriteString(name)
+
+	if !strings.ContainsRune(value, '\n') {
+		buf.WriteByte('=')
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+
+		return
+	}
+
+	buf.WriteByte('\n')
+	_ = binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
+
+// journaldWriter sends datagrams to the journal socket, it connects when the first entry is written.
+// Each entry is sent as a single datagram, so entries larger than the socket's maximum datagram size,
+// usually a few hundred kilobytes, cannot be written.
+type journaldWriter struct {
+	address string
+
+	lock sync.Mutex
+	conn net.Conn
+}
+
+func (w *journaldWriter) write(b []byte) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.conn == nil {
+		conn, err := net.Dial("unixgram", w.address)
+
+		if err != nil {
+			return fmt.Errorf("could not connect to journald at %s: %w", w.address, err)
+		}
+
+		w.conn = conn
+	}
+
+	if _, err := w.conn.Write(b); err != nil {
+		_ = w.conn.Close()
+		w.conn = nil
+
+		return fmt.Errorf("could not write to journald: %w", err)
+	}
+
+	return nil
+}
+
+// Close closes the connection to the journal
+func (w *journaldWriter) Close() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.conn == nil {
+		return nil
+	}
+
+	err := w.conn.Close()
+	w.conn = nil
+
+	return err
+}
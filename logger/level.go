@@ -0,0 +1,308 @@
+package logger
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/birchwood-langham/web-service-bootstrap/config"
+)
+
+// Levels are the log level of a logger and the levels of its named components, they can be changed at runtime
+// without rebuilding the logger. The package level functions, such as SetLevel and Named, use the levels of the
+// global logger, a logger created with NewFromSource has levels of its own, returned by Logger.Levels.
+type Levels struct {
+	level zap.AtomicLevel
+	log   atomic.Value
+
+	lock        sync.Mutex
+	base        zapcore.Level
+	revertTimer *time.Timer
+	source      *config.Source
+	components  map[string]*componentLevel
+}
+
+// defaultLevels are the levels of the global logger, they are kept when the global logger is replaced
+var defaultLevels = NewLevels()
+
+// NewLevels creates levels at INFO, without components
+func NewLevels() *Levels {
+	return &Levels{
+		level:      zap.NewAtomicLevelAt(zapcore.InfoLevel),
+		base:       zapcore.InfoLevel,
+		components: make(map[string]*componentLevel),
+	}
+}
+
+// DefaultLevels returns the levels of the global logger
+func DefaultLevels() *Levels {
+	return defaultLevels
+}
+
+// Level returns the atomic level used by the application logger
+func Level() zap.AtomicLevel {
+	return defaultLevels.Level()
+}
+
+// Level returns the atomic level of the logger
+func (lv *Levels) Level() zap.AtomicLevel {
+	return lv.level
+}
+
+// sugar returns the logger the changes of the levels are logged to, the logger the levels belong to, or the
+// global logger
+func (lv *Levels) sugar() *zap.SugaredLogger {
+	if l, ok := lv.log.Load().(*zap.Logger); ok {
+		return l.Sugar()
+	}
+
+	return zap.S()
+}
+
+// SetLevel changes the application log level, cancelling any pending revert
+// of a temporary level set by SetLevelFor
+func SetLevel(level zapcore.Level) {
+	defaultLevels.SetLevel(level)
+}
+
+// SetLevel changes the log level, cancelling any pending revert of a temporary level set by SetLevelFor
+func (lv *Levels) SetLevel(level zapcore.Level) {
+	lv.lock.Lock()
+	defer lv.lock.Unlock()
+
+	lv.stopRevert()
+
+	lv.base = level
+	lv.level.SetLevel(level)
+}
+
+// SetLevelFor changes the application log level temporarily, reverting to the level set by SetLevel
+// once the duration has elapsed. If the duration is zero or negative the level is not reverted.
+func SetLevelFor(level zapcore.Level, d time.Duration) {
+	defaultLevels.SetLevelFor(level, d)
+}
+
+// SetLevelFor changes the log level temporarily, see the package level SetLevelFor
+func (lv *Levels) SetLevelFor(level zapcore.Level, d time.Duration) {
+	lv.lock.Lock()
+	defer lv.lock.Unlock()
+
+	lv.setLevelFor(level, d)
+}
+
+func (lv *Levels) setLevelFor(level zapcore.Level, d time.Duration) {
+	lv.stopRevert()
+
+	lv.level.SetLevel(level)
+
+	if d <= 0 {
+		lv.base = level
+		return
+	}
+
+	lv.revertTimer = time.AfterFunc(d, func() {
+		lv.lock.Lock()
+		defer lv.lock.Unlock()
+
+		lv.revertTimer = nil
+		lv.level.SetLevel(lv.base)
+		lv.sugar().Infof("Log level reverted to %s", lv.base.CapitalString())
+	})
+}
+
+func (lv *Levels) stopRevert() {
+	if lv.revertTimer != nil {
+		lv.revertTimer.Stop()
+		lv.revertTimer = nil
+	}
+}
+
+// stopReverts cancels the pending reverts of the log level and of the levels of the components
+func (lv *Levels) stopReverts() {
+	lv.lock.Lock()
+	defer lv.lock.Unlock()
+
+	lv.stopRevert()
+
+	for _, c := range lv.components {
+		c.stopRevert()
+	}
+}
+
+// IncreaseVerbosity steps the application log level one level towards DEBUG, reverting after
+// the given duration, and returns the new level
+func IncreaseVerbosity(d time.Duration) zapcore.Level {
+	return defaultLevels.IncreaseVerbosity(d)
+}
+
+// IncreaseVerbosity steps the log level one level towards DEBUG, reverting after the given duration, and
+// returns the new level
+func (lv *Levels) IncreaseVerbosity(d time.Duration) zapcore.Level {
+	return lv.stepLevel(-1, d)
+}
+
+// DecreaseVerbosity steps the application log level one level towards FATAL, reverting after
+// the given duration, and returns the new level
+func DecreaseVerbosity(d time.Duration) zapcore.Level {
+	return defaultLevels.DecreaseVerbosity(d)
+}
+
+// DecreaseVerbosity steps the log level one level towards FATAL, reverting after the given duration, and
+// returns the new level
+func (lv *Levels) DecreaseVerbosity(d time.Duration) zapcore.Level {
+	return lv.stepLevel(1, d)
+}
+
+func (lv *Levels) stepLevel(step int, d time.Duration) zapcore.Level {
+	lv.lock.Lock()
+	defer lv.lock.Unlock()
+
+	level := lv.level.Level() + zapcore.Level(step)
+
+	if level < zapcore.DebugLevel {
+		level = zapcore.DebugLevel
+	}
+
+	if level > zapcore.FatalLevel {
+		level = zapcore.FatalLevel
+	}
+
+	lv.setLevelFor(level, d)
+
+	return level
+}
+
+// LevelRevertAfter returns the duration after which a level changed at runtime
+// is reverted, as defined in the application configuration file
+func LevelRevertAfter() time.Duration {
+	return config.Global().GetDuration(config.LogLevelRevertAfterKey)
+}
+
+// RevertAfter returns the duration after which a level changed at runtime is reverted, as defined in the
+// configuration source the levels were configured from, or the application configuration file
+func (lv *Levels) RevertAfter() time.Duration {
+	lv.lock.Lock()
+	src := lv.source
+	lv.lock.Unlock()
+
+	if src == nil {
+		return LevelRevertAfter()
+	}
+
+	return src.GetDuration(config.LogLevelRevertAfterKey)
+}
+
+// Configure sets the log level and the levels of the components from the configuration source, components
+// that are not configured revert to following the log level
+func (lv *Levels) Configure(src *config.Source) error {
+	levels, err := componentLevelsFromSource(src)
+
+	if err != nil {
+		return err
+	}
+
+	lv.SetLevel(LevelFromSource(src))
+
+	lv.lock.Lock()
+	defer lv.lock.Unlock()
+
+	lv.source = src
+	lv.applyComponentLevels(levels)
+
+	return nil
+}
+
+// ParseLevel converts a level name such as DEBUG or info into a zap level
+func ParseLevel(name string) (zapcore.Level, error) {
+	var level zapcore.Level
+
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return level, fmt.Errorf("unrecognized log level: %s", name)
+	}
+
+	return level, nil
+}
+
+type levelPayload struct {
+	Level       string `json:"level"`
+	RevertAfter string `json:"revert_after,omitempty"`
+}
+
+var errMethodNotAllowed = errors.New("method not allowed")
+
+// LevelHandler returns an http.Handler that reports the application log level on GET, and changes it on PUT
+// with a JSON body such as {"level": "debug", "revert_after": "10m"}. If revert_after is omitted the level is
+// reverted after the duration configured by log.level-revert-after.
+func LevelHandler() http.Handler {
+	return defaultLevels.Handler()
+}
+
+// Handler returns an http.Handler that reports and changes the log level, see LevelHandler
+func (lv *Levels) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			level, revertAfter, ok := lv.readLevelRequest(w, r)
+
+			if !ok {
+				return
+			}
+
+			lv.SetLevelFor(level, revertAfter)
+			// logged as a warning so that the change is visible at most levels
+			lv.sugar().Warnf("Log level changed to %s", level.CapitalString())
+		default:
+			writeLevelError(w, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		writeLevel(w, http.StatusOK, levelPayload{Level: lv.level.Level().CapitalString()})
+	})
+}
+
+// readLevelRequest decodes the level and revert duration from the body of a request to change a
+// log level, if the request is invalid an error response is written and ok is false
+func (lv *Levels) readLevelRequest(w http.ResponseWriter, r *http.Request) (level zapcore.Level, revertAfter time.Duration, ok bool) {
+	var req levelPayload
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeLevelError(w, http.StatusBadRequest, fmt.Errorf("could not decode request: %w", err))
+		return
+	}
+
+	level, err := ParseLevel(req.Level)
+
+	if err != nil {
+		writeLevelError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	revertAfter = lv.RevertAfter()
+
+	if req.RevertAfter != "" {
+		if revertAfter, err = time.ParseDuration(req.RevertAfter); err != nil {
+			writeLevelError(w, http.StatusBadRequest, fmt.Errorf("invalid revert_after: %w", err))
+			return
+		}
+	}
+
+	return level, revertAfter, true
+}
+
+func writeLevelError(w http.ResponseWriter, code int, err error) {
+	writeLevel(w, code, map[string]string{"error": err.Error()})
+}
+
+func writeLevel(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(payload)
+}
@@ -0,0 +1,106 @@
+package logger
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLevelHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		body     string
+		wantCode int
+		want     string
+	}{
+		{"Test get level", http.MethodGet, "", http.StatusOK, "INFO"},
+		{"Test set level", http.MethodPut, `{"level": "debug"}`, http.StatusOK, "DEBUG"},
+		{"Test set level with revert", http.MethodPut, `{"level": "warn", "revert_after": "1h"}`, http.StatusOK, "WARN"},
+		{"Test unknown level", http.MethodPut, `{"level": "loud"}`, http.StatusBadRequest, ""},
+		{"Test invalid revert", http.MethodPut, `{"level": "debug", "revert_after": "soon"}`, http.StatusBadRequest, ""},
+		{"Test invalid body", http.MethodPut, `debug`, http.StatusBadRequest, ""},
+		{"Test method not allowed", http.MethodPost, `{"level": "debug"}`, http.StatusMethodNotAllowed, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lv := NewLevels()
+			defer lv.stopReverts()
+
+			w := httptest.NewRecorder()
+			lv.Handler().ServeHTTP(w, httptest.NewRequest(tt.method, "/log/level", strings.NewReader(tt.body)))
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantCode, w.Body.String())
+			}
+
+			if tt.want == "" {
+				return
+			}
+
+			var got levelPayload
+
+			if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+				t.Fatal(err)
+			}
+
+			if got.Level != tt.want {
+				t.Errorf("level = %s, want %s", got.Level, tt.want)
+			}
+
+			if level := lv.Level().Level().CapitalString(); level != tt.want {
+				t.Errorf("log level = %s, want %s", level, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetLevelFor(t *testing.T) {
+	tests := []struct {
+		name  string
+		set   func(lv *Levels) zapcore.Level
+		want  zapcore.Level
+		after zapcore.Level
+	}{
+		{"Test kept without duration", func(lv *Levels) zapcore.Level { lv.SetLevelFor(zapcore.DebugLevel, 0); return zapcore.DebugLevel }, zapcore.DebugLevel, zapcore.DebugLevel},
+		{"Test reverted after duration", func(lv *Levels) zapcore.Level {
+			lv.SetLevelFor(zapcore.DebugLevel, 10*time.Millisecond)
+			return zapcore.DebugLevel
+		}, zapcore.DebugLevel, zapcore.InfoLevel},
+		{"Test increase verbosity", func(lv *Levels) zapcore.Level { return lv.IncreaseVerbosity(10 * time.Millisecond) }, zapcore.DebugLevel, zapcore.InfoLevel},
+		{"Test decrease verbosity", func(lv *Levels) zapcore.Level { return lv.DecreaseVerbosity(10 * time.Millisecond) }, zapcore.WarnLevel, zapcore.InfoLevel},
+		{"Test verbosity is bounded", func(lv *Levels) zapcore.Level {
+			lv.SetLevel(zapcore.DebugLevel)
+			return lv.IncreaseVerbosity(0)
+		}, zapcore.DebugLevel, zapcore.DebugLevel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lv := NewLevels()
+			defer lv.stopReverts()
+
+			if got := tt.set(lv); got != tt.want || lv.Level().Level() != tt.want {
+				t.Fatalf("level = %v (returned %v), want %v", lv.Level().Level(), got, tt.want)
+			}
+
+			deadline := time.Now().Add(time.Second)
+
+			for lv.Level().Level() != tt.after && time.Now().Before(deadline) {
+				time.Sleep(5 * time.Millisecond)
+			}
+
+			time.Sleep(20 * time.Millisecond)
+
+			if got := lv.Level().Level(); got != tt.after {
+				t.Errorf("level after the revert = %v, want %v", got, tt.after)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package logger
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+var bufferPool = buffer.NewPool()
+
+// logfmtEncoder encodes entries as key=value pairs, see https://brandur.org/logfmt.
+// The context fields are written in key order after the entry's time, level, logger name,
+// caller and message, nested objects are flattened into dotted keys.
+type logfmtEncoder struct {
+	*zapcore.MapObjectEncoder
+	cfg zapcore.EncoderConfig
+}
+
+// NewLogfmtEncoder creates an encoder that writes entries in logfmt format
+func NewLogfmtEncoder(cfg zapcore.EncoderConfig) zapcore.Encoder {
+	return &logfmtEncoder{
+		MapObjectEncoder: zapcore.NewMapObjectEncoder(),
+		cfg:              cfg,
+	}
+}
+
+func (e *logfmtEncoder) Clone() zapcore.Encoder {
+	clone := NewLogfmtEncoder(e.cfg).(*logfmtEncoder)
+
+	for k, v := range e.Fields {
+		clone.Fields[k] = v
+	}
+
+	return clone
+}
+
+func (e *logfmtEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	final := e.Clone().(*logfmtEncoder)
+
+	for _, f := range fields {
+		f.AddTo(final.MapObjectEncoder)
+	}
+
+	buf := bufferPool.Get()
+
+	if e.cfg.TimeKey != "" {
+		appendPair(buf, e.cfg.TimeKey, ent.Time.Format("2006-01-02T15:04:05.000Z0700"))
+	}
+
+	if e.cfg.LevelKey != "" {
+		appendPair(buf, e.cfg.LevelKey, ent.Level.String())
+	}
+
+	if e.cfg.NameKey != "" && ent.LoggerName != "" {
+		appendPair(buf, e.cfg.NameKey, ent.LoggerName)
+	}
+
+	if e.cfg.CallerKey != "" && ent.Caller.Defined {
+		appendPair(buf, e.cfg.CallerKey, ent.Caller.TrimmedPath())
+	}
+
+	if e.cfg.MessageKey != "" {
+		appendPair(buf, e.cfg.MessageKey, ent.Message)
+	}
+
+	appendFields(buf, "", final.Fields)
+
+	if e.cfg.StacktraceKey != "" && ent.Stack != "" {
+		appendPair(buf, e.cfg.StacktraceKey, ent.Stack)
+	}
+
+	if e.cfg.LineEnding != "" {
+		buf.AppendString(e.cfg.LineEnding)
+	} else {
+		buf.AppendString(zapcore.DefaultLineEnding)
+	}
+
+	return buf, nil
+}
+
+func appendFields(buf *buffer.Buffer, prefix string, fields map[string]interface{}) {
+	flattenFields(prefix, fields, func(key, value string) {
+		appendPair(buf, key, value)
+	})
+}
+
+// flattenFields calls fn with each of the fields in key order, the keys of nested objects
+// are prefixed by the key of the object, e.g. user.id
+func flattenFields(prefix string, fields map[string]interface{}, fn func(key, value string)) {
+	keys := make([]string, 0, len(fields))
+
+	for k := range fields {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		key := k
+
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+
+		if nested, ok := fields[k].(map[string]interface{}); ok {
+			flattenFields(key, nested, fn)
+			continue
+		}
+
+		fn(key, formatValue(fields[k]))
+	}
+}
+
+func formatValue(v interface{}) string {
+	switch value := v.(type) {
+	case string:
+		return value
+	case []byte:
+		return base64.StdEncoding.EncodeToString(value)
+	case time.Time:
+		return value.Format(time.RFC3339Nano)
+	case time.Duration:
+		return value.String()
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(value), 'f', -1, 32)
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, uintptr, complex64, complex128:
+		return fmt.Sprint(value)
+	case fmt.Stringer:
+		return value.String()
+	}
+
+	// arrays and reflected values are written as JSON
+	if b, err := json.Marshal(v); err == nil {
+		return string(b)
+	}
+
+	return fmt.Sprint(v)
+}
+
+func appendPair(buf *buffer.Buffer, key, value string) {
+	if buf.Len() > 0 {
+		buf.AppendByte(' ')
+	}
+
+	buf.AppendString(key)
+	buf.AppendByte('=')
+
+	if needsQuotes(value) {
+		buf.AppendString(strconv.Quote(value))
+	} else {
+		buf.AppendString(value)
+	}
+}
+
+func needsQuotes(s string) bool {
+	if s == "" {
+		return true
+	}
+
+	return strings.IndexFunc(s, func(r rune) bool {
+		return r <= ' ' || r == '=' || r == '"' || !unicode.IsPrint(r)
+	}) >= 0
+}
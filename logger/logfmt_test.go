@@ -0,0 +1,76 @@
+package logger
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLogfmtEncoder(t *testing.T) {
+	ent := zapcore.Entry{
+		Level:      zapcore.InfoLevel,
+		Time:       time.Date(2020, 6, 1, 12, 30, 0, 0, time.UTC),
+		LoggerName: "db",
+		Message:    "Opened connection",
+	}
+
+	tests := []struct {
+		name    string
+		context []zapcore.Field
+		fields  []zapcore.Field
+		want    string
+	}{
+		{
+			"Test no fields",
+			nil,
+			nil,
+			`ts=2020-06-01T12:30:00.000Z level=info logger=db msg="Opened connection"` + "\n",
+		},
+		{
+			"Test fields are sorted",
+			nil,
+			[]zapcore.Field{zap.Int("port", 5432), zap.String("host", "localhost"), zap.Bool("tls", true)},
+			`ts=2020-06-01T12:30:00.000Z level=info logger=db msg="Opened connection" host=localhost port=5432 tls=true` + "\n",
+		},
+		{
+			"Test values are quoted",
+			nil,
+			[]zapcore.Field{zap.String("empty", ""), zap.String("query", "a=b"), zap.Error(errors.New(`quote " this`))},
+			`ts=2020-06-01T12:30:00.000Z level=info logger=db msg="Opened connection" empty="" error="quote \" this" query="a=b"` + "\n",
+		},
+		{
+			"Test context fields and nested objects",
+			[]zapcore.Field{zap.String("app", "MyApp")},
+			[]zapcore.Field{zap.Duration("elapsed", time.Second), zap.Namespace("pool"), zap.Int("size", 10), zap.Strings("tags", []string{"a", "b"})},
+			`ts=2020-06-01T12:30:00.000Z level=info logger=db msg="Opened connection" app=MyApp elapsed=1s pool.size=10 pool.tags="[\"a\",\"b\"]"` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enc := NewLogfmtEncoder(zapcore.EncoderConfig{
+				TimeKey:    "ts",
+				LevelKey:   "level",
+				NameKey:    "logger",
+				MessageKey: "msg",
+			})
+
+			for _, f := range tt.context {
+				f.AddTo(enc)
+			}
+
+			buf, err := enc.EncodeEntry(ent, tt.fields)
+
+			if err != nil {
+				t.Fatalf("EncodeEntry() error = %v", err)
+			}
+
+			if got := buf.String(); got != tt.want {
+				t.Errorf("EncodeEntry() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -5,21 +5,14 @@ import (
 	"io"
 	"os"
 	"strings"
-	"sync"
 
 	"github.com/natefinch/lumberjack"
-	"github.com/spf13/viper"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 
 	"github.com/birchwood-langham/web-service-bootstrap/config"
 )
 
-var once sync.Once
-var syncer zapcore.WriteSyncer
-var core zapcore.Core
-var log *zap.Logger
-
 // ZapConfig returns the bootstrap default zap configuration
 func ZapConfig() zapcore.EncoderConfig {
 	encoderConfig := zap.NewProductionEncoderConfig()
@@ -34,16 +27,9 @@ func ZapEncoder() zapcore.Encoder {
 	return zapcore.NewConsoleEncoder(ZapConfig())
 }
 
-// ZapWriter initializes and returns a multi-sync writer
-// with the given writer and stdout
-// If the writer has already been initialized, it will return
-// the initialized writer
+// ZapWriter returns a multi-sync writer with the given writer and stdout
 func ZapWriter(writer io.Writer) zapcore.WriteSyncer {
-	if syncer == nil {
-		syncer = zapcore.NewMultiWriteSyncer(zapcore.AddSync(writer), zapcore.AddSync(os.Stdout))
-	}
-
-	return syncer
+	return zapcore.NewMultiWriteSyncer(zapcore.AddSync(writer), zapcore.AddSync(os.Stdout))
 }
 
 // LumberjackLogger creates a lumberjack logger with the given parameters
@@ -57,49 +43,118 @@ func LumberjackLogger(fileName string, maxSize, maxBackups, maxAge int, compress
 	}
 }
 
-// ZapCore returns the zap core used by the logger
+// ZapCore returns the zap core used by the global logger
 func ZapCore() (zapcore.Core, error) {
-	if core == nil {
+	globalLock.Lock()
+	defer globalLock.Unlock()
+
+	if global == nil {
 		return nil, errors.New("ZapCore has not been initialized")
 	}
 
-	return core, nil
+	return &levelFilterCore{Core: &globalCore{}, level: defaultLevels.level}, nil
 }
 
 // DefaultLumberjackLogger returns the lumberjack logger using default settings
 // provided in the application settings
 func DefaultLumberjackLogger() *lumberjack.Logger {
+	return LumberjackLoggerFromSource(config.Global())
+}
+
+// LumberjackLoggerFromSource returns the lumberjack logger using the log file settings of the configuration source
+func LumberjackLoggerFromSource(src *config.Source) *lumberjack.Logger {
 	return LumberjackLogger(
-		viper.GetString(config.LogFilePathKey),
-		viper.GetInt(config.LogFileMaxSize),
-		viper.GetInt(config.LogFileMaxBackups),
-		viper.GetInt(config.LogFileMaxAge),
-		viper.GetBool(config.LogFileCompress),
+		src.GetString(config.LogFilePathKey),
+		src.GetInt(config.LogFileMaxSize),
+		src.GetInt(config.LogFileMaxBackups),
+		src.GetInt(config.LogFileMaxAge),
+		src.GetBool(config.LogFileCompress),
 	)
 }
 
-// New initializes the zap core and logger for the application
-// If the core and logger has already been initialized, New returns
-// the existing logger
+// New creates a logger at the given level that writes to the writer and stdout using the console encoder.
+// Each call creates a new logger, independent of the global logger, use Configure or ReplaceGlobals
+// to change the global logger.
 func New(level zapcore.Level, writer io.Writer) *zap.Logger {
-	once.Do(func() {
-		core = zapcore.NewCore(ZapEncoder(), ZapWriter(writer), level)
-		log = zap.New(core, zap.AddCaller())
-	})
+	l, _ := NewBuilder().
+		Level(level).
+		Writer(ZapEncoder(), ZapWriter(writer)).
+		Build()
 
-	defer func() {
-		_ = log.Sync()
-	}()
+	return l.Logger
+}
 
-	return log
+// NewFromConfig creates a logger using the format, sinks, redaction and sampling defined in the application configuration
+// file, it writes at the application log level. The logger is independent of the global logger and
+// must be closed when it is no longer used, unless it is made the global logger with ReplaceGlobals.
+func NewFromConfig() (*Logger, error) {
+	return newFromSource(config.Global(), defaultLevels)
+}
+
+// NewFromSource creates a logger using the format, sinks, redaction and sampling defined in the configuration
+// source. It has levels of its own, configured from the log level and component levels of the source and
+// returned by Levels, so that changing the application log level at runtime does not affect it. The logger
+// must be closed when it is no longer used.
+func NewFromSource(src *config.Source) (*Logger, error) {
+	levels := NewLevels()
+
+	if err := levels.Configure(src); err != nil {
+		return nil, err
+	}
+
+	l, err := newFromSource(src, levels)
+
+	if err != nil {
+		return nil, err
+	}
+
+	l.ownsLevels = true
+	// the changes of the levels are logged by the logger they belong to
+	levels.log.Store(l.Logger)
+
+	return l, nil
+}
+
+func newFromSource(src *config.Source, levels *Levels) (*Logger, error) {
+	sinks, err := SinksFromSource(src)
+
+	if err != nil {
+		return nil, err
+	}
+
+	redactor, err := RedactorFromSource(src)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sampling, err := SamplingFromSource(src)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewBuilder().
+		Levels(levels).
+		Sinks(sinks...).
+		Redact(redactor).
+		Sample(sampling).
+		Dedup(src.GetDuration(config.LogSamplingDedupKey)).
+		Build()
 }
 
 // ApplicationLogLevel returns the log level defined in the
-// application configuration file
+// application configuration file, the level can be changed at runtime
+// without changing the configuration file with SetLevel or SetLevelFor
 func ApplicationLogLevel() zapcore.Level {
+	return LevelFromSource(config.Global())
+}
+
+// LevelFromSource returns the log level defined in the configuration source, INFO if it has not been defined
+func LevelFromSource(src *config.Source) zapcore.Level {
 	var level zapcore.Level
 
-	switch strings.ToUpper(viper.GetString(config.LogLevelKey)) {
+	switch strings.ToUpper(src.GetString(config.LogLevelKey)) {
 	case "DEBUG":
 		level = zapcore.DebugLevel
 	case "INFO":
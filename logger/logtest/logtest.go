@@ -0,0 +1,160 @@
+// Package logtest captures the entries written to the global logger in memory, so that tests can check
+// what the code under test has logged:
+//
+//	func TestHandler(t *testing.T) {
+//		logs := logtest.Capture(t)
+//
+//		handler(w, r)
+//
+//		logs.AssertLogged(t, zapcore.ErrorLevel, "Could not write response: broken pipe")
+//	}
+//
+// Entries written with zap.L(), zap.S() and logger.Named are captured, including by loggers that were
+// obtained before Capture was called. As the global logger is replaced, tests that capture the logs must not
+// run in parallel.
+package logtest
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/birchwood-langham/web-service-bootstrap/logger"
+)
+
+// Logs holds the captured entries, the methods of observer.ObservedLogs can be used to filter them
+type Logs struct {
+	*observer.ObservedLogs
+}
+
+// Capture replaces the global logger with one that captures every entry in memory, including debug entries.
+// The previous global logger and log level are restored when the test finishes.
+func Capture(t testing.TB) *Logs {
+	return CaptureLevel(t, zapcore.DebugLevel)
+}
+
+// CaptureLevel replaces the global logger with one that captures the entries at or above the given level
+// in memory. The previous global logger and log level are restored when the test finishes.
+func CaptureLevel(t testing.TB, level zapcore.Level) *Logs {
+	t.Helper()
+
+	core, logs := observer.New(zapcore.DebugLevel)
+
+	l, err := logger.NewBuilder().Core(core).Build()
+
+	if err != nil {
+		t.Fatalf("could not create the capturing logger: %v", err)
+	}
+
+	previous := logger.Level().Level()
+	restore := logger.Override(l)
+	logger.SetLevel(level)
+
+	t.Cleanup(func() {
+		logger.SetLevel(previous)
+		restore()
+	})
+
+	return &Logs{ObservedLogs: logs}
+}
+
+// Messages returns the messages of the captured entries in the order they were written
+func (l *Logs) Messages() []string {
+	entries := l.All()
+	messages := make([]string, 0, len(entries))
+
+	for _, e := range entries {
+		messages = append(messages, e.Message)
+	}
+
+	return messages
+}
+
+// FilterLevel returns the entries written at the given level
+func (l *Logs) FilterLevel(level zapcore.Level) *Logs {
+	return l.filter(func(e observer.LoggedEntry) bool { return e.Level == level })
+}
+
+func (l *Logs) filter(match func(observer.LoggedEntry) bool) *Logs {
+	core, logs := observer.New(zapcore.DebugLevel)
+
+	for _, e := range l.All() {
+		if match(e) {
+			_ = core.Write(e.Entry, e.Context)
+		}
+	}
+
+	return &Logs{ObservedLogs: logs}
+}
+
+// Contains reports whether an entry has been written at the level with the message and, at least,
+// the given fields
+func (l *Logs) Contains(level zapcore.Level, msg string, fields ...zapcore.Field) bool {
+	for _, e := range l.All() {
+		if e.Level == level && e.Message == msg && hasFields(e, fields) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasFields(e observer.LoggedEntry, fields []zapcore.Field) bool {
+	context := e.ContextMap()
+
+	for _, f := range fields {
+		enc := zapcore.NewMapObjectEncoder()
+		f.AddTo(enc)
+
+		for k, v := range enc.Fields {
+			if !reflect.DeepEqual(context[k], v) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// AssertLogged fails the test if an entry has not been written at the level with the message and fields
+func (l *Logs) AssertLogged(t testing.TB, level zapcore.Level, msg string, fields ...zapcore.Field) {
+	t.Helper()
+
+	if !l.Contains(level, msg, fields...) {
+		t.Errorf("no %s entry %q with fields %s, the captured entries are:\n%s", level.CapitalString(), msg, describeFields(fields), l)
+	}
+}
+
+// AssertNotLogged fails the test if an entry has been written at the level with the message
+func (l *Logs) AssertNotLogged(t testing.TB, level zapcore.Level, msg string) {
+	t.Helper()
+
+	if l.Contains(level, msg) {
+		t.Errorf("unexpected %s entry %q", level.CapitalString(), msg)
+	}
+}
+
+func describeFields(fields []zapcore.Field) string {
+	enc := zapcore.NewMapObjectEncoder()
+
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+
+	return fmt.Sprint(enc.Fields)
+}
+
+// String lists the captured entries, one per line
+func (l *Logs) String() string {
+	var b strings.Builder
+
+	for _, e := range l.All() {
+		fmt.Fprintf(&b, "  %s %q %v\n", e.Level.CapitalString(), e.Message, e.ContextMap())
+	}
+
+	return b.String()
+}
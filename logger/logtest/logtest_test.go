@@ -0,0 +1,64 @@
+package logtest
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/birchwood-langham/web-service-bootstrap/logger"
+)
+
+func TestCapture(t *testing.T) {
+	named := logger.Named("db")
+
+	var outer *Logs
+
+	t.Run("Test capture", func(t *testing.T) {
+		outer = Capture(t)
+
+		zap.S().Debugf("connecting to %s", "db1")
+		named.Warn("slow query", zap.Int("ms", 1500), zap.String("table", "users"))
+
+		outer.AssertLogged(t, zapcore.DebugLevel, "connecting to db1")
+		outer.AssertLogged(t, zapcore.WarnLevel, "slow query", zap.Int("ms", 1500))
+		outer.AssertNotLogged(t, zapcore.ErrorLevel, "slow query")
+
+		if outer.Contains(zapcore.WarnLevel, "slow query", zap.Int("ms", 10)) {
+			t.Error("entry matched with a different field value")
+		}
+
+		if got := outer.FilterLevel(zapcore.WarnLevel).Len(); got != 1 {
+			t.Errorf("got %d warnings, want 1", got)
+		}
+
+		t.Run("Test nested capture at level", func(t *testing.T) {
+			inner := CaptureLevel(t, zapcore.InfoLevel)
+
+			zap.S().Debug("hidden")
+			zap.L().Info("shown")
+
+			if got := inner.Messages(); len(got) != 1 || got[0] != "shown" {
+				t.Errorf("captured %v, want [shown]", got)
+			}
+		})
+
+		zap.L().Info("after nested capture")
+		zap.S().Debug("debug restored")
+	})
+
+	zap.L().Info("after capture")
+
+	want := []string{"connecting to db1", "slow query", "after nested capture", "debug restored"}
+	got := outer.Messages()
+
+	if len(got) != len(want) {
+		t.Fatalf("captured %v, want %v", got, want)
+	}
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("message %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,406 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/spf13/cast"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/birchwood-langham/web-service-bootstrap/config"
+)
+
+// sensitiveKeys are the words that mark a field as sensitive when they appear in its name,
+// names are compared in lower case with any punctuation removed, so apiKey, api_key and
+// x-api-key all contain apikey
+var sensitiveKeys = []string{"password", "passwd", "secret", "token", "authorization", "cookie", "credential", "privatekey", "apikey"}
+
+// sensitiveHeaders are the HTTP headers whose values are always redacted
+var sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+// Pattern matches sensitive values within log messages and fields
+type Pattern struct {
+	// Name identifies the pattern in the configuration
+	Name string
+	// Regexp finds candidate values
+	Regexp *regexp.Regexp
+	// Match, if set, confirms that a candidate value is sensitive, e.g. with a checksum
+	Match func(string) bool
+}
+
+var (
+	// CreditCardPattern matches payment card numbers of 13 to 19 digits, optionally separated by spaces or dashes,
+	// that pass the Luhn checksum
+	CreditCardPattern = Pattern{
+		Name:   "credit-card",
+		Regexp: regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`),
+		Match:  luhn,
+	}
+	// EmailPattern matches email addresses
+	EmailPattern = Pattern{
+		Name:   "email",
+		Regexp: regexp.MustCompile(`(?i)\b[a-z0-9._%+-]+@[a-z0-9.-]+\.[a-z]{2,}\b`),
+	}
+)
+
+var builtinPatterns = map[string]Pattern{
+	CreditCardPattern.Name: CreditCardPattern,
+	EmailPattern.Name:      EmailPattern,
+}
+
+// ParsePattern returns the built-in pattern with the given name, i.e. credit-card or email, or
+// compiles the pattern as a regular expression
+func ParsePattern(pattern string) (Pattern, error) {
+	if p, ok := builtinPatterns[strings.ToLower(pattern)]; ok {
+		return p, nil
+	}
+
+	re, err := regexp.Compile(pattern)
+
+	if err != nil {
+		return Pattern{}, fmt.Errorf("invalid redaction pattern %s: %w", pattern, err)
+	}
+
+	return Pattern{Name: pattern, Regexp: re}, nil
+}
+
+func luhn(s string) bool {
+	sum := 0
+	digits := 0
+
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i] < '0' || s[i] > '9' {
+			continue
+		}
+
+		d := int(s[i] - '0')
+
+		if digits%2 == 1 {
+			d *= 2
+
+			if d > 9 {
+				d -= 9
+			}
+		}
+
+		sum += d
+		digits++
+	}
+
+	return digits >= 13 && digits <= 19 && sum%10 == 0
+}
+
+// Redactor replaces sensitive values with config.RedactedValue, the value of a field is redacted if its
+// name is sensitive, and any part of a message or field value that matches one of its patterns is redacted
+type Redactor struct {
+	keys     []string
+	headers  map[string]bool
+	patterns []Pattern
+}
+
+// NewRedactor creates a redactor that redacts fields whose names contain words such as password, token
+// or authorization, and the Authorization and Cookie headers. It does not have any patterns.
+func NewRedactor() *Redactor {
+	r := &Redactor{headers: make(map[string]bool)}
+
+	return r.Keys(sensitiveKeys...).Headers(sensitiveHeaders...)
+}
+
+// Keys adds words that mark a field as sensitive when they appear in its name
+func (r *Redactor) Keys(keys ...string) *Redactor {
+	for _, k := range keys {
+		if k = normalizeKey(k); k != "" {
+			r.keys = append(r.keys, k)
+		}
+	}
+
+	return r
+}
+
+// Headers adds HTTP headers whose values are redacted by RedactHeaders
+func (r *Redactor) Headers(headers ...string) *Redactor {
+	for _, h := range headers {
+		r.headers[http.CanonicalHeaderKey(h)] = true
+	}
+
+	return r
+}
+
+// Patterns adds patterns of values that are redacted wherever they appear
+func (r *Redactor) Patterns(patterns ...Pattern) *Redactor {
+	r.patterns = append(r.patterns, patterns...)
+	return r
+}
+
+func normalizeKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+
+		return -1
+	}, key)
+}
+
+// SensitiveKey reports whether the value of a field with the given name is redacted
+func (r *Redactor) SensitiveKey(key string) bool {
+	key = normalizeKey(key)
+
+	for _, k := range r.keys {
+		if strings.Contains(key, k) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Scrub returns the string with every part that matches one of the redactor's patterns redacted
+func (r *Redactor) Scrub(s string) string {
+	for _, p := range r.patterns {
+		match := p.Match
+
+		s = p.Regexp.ReplaceAllStringFunc(s, func(m string) string {
+			if match != nil && !match(m) {
+				return m
+			}
+
+			return config.RedactedValue
+		})
+	}
+
+	return s
+}
+
+// RedactHeaders returns a copy of the headers with the values of sensitive headers redacted, for logging requests
+func (r *Redactor) RedactHeaders(h http.Header) http.Header {
+	redacted := make(http.Header, len(h))
+
+	for name, values := range h {
+		if r.headers[http.CanonicalHeaderKey(name)] || r.SensitiveKey(name) {
+			redacted[name] = []string{config.RedactedValue}
+			continue
+		}
+
+		scrubbed := make([]string, len(values))
+
+		for i, v := range values {
+			scrubbed[i] = r.Scrub(v)
+		}
+
+		redacted[name] = scrubbed
+	}
+
+	return redacted
+}
+
+// Fields returns the fields with sensitive values redacted, fields that do not need to be redacted are
+// returned unchanged. Objects, arrays and reflected values are inspected by encoding them, a field
+// holding one of them is replaced by a reflected value if any part of it is redacted.
+func (r *Redactor) Fields(fields []zapcore.Field) []zapcore.Field {
+	redacted := make([]zapcore.Field, 0, len(fields))
+
+	for _, f := range fields {
+		redacted = append(redacted, r.field(f)...)
+	}
+
+	return redacted
+}
+
+func (r *Redactor) field(f zapcore.Field) []zapcore.Field {
+	switch f.Type {
+	case zapcore.NamespaceType, zapcore.SkipType:
+		return []zapcore.Field{f}
+	}
+
+	if r.SensitiveKey(f.Key) {
+		return []zapcore.Field{zap.String(f.Key, config.RedactedValue)}
+	}
+
+	switch f.Type {
+	case zapcore.StringType:
+		f.String = r.Scrub(f.String)
+		return []zapcore.Field{f}
+	case zapcore.ByteStringType, zapcore.StringerType, zapcore.ErrorType, zapcore.ObjectMarshalerType, zapcore.ArrayMarshalerType:
+		return r.encodedField(f)
+	case zapcore.ReflectType:
+		return r.reflectedField(f)
+	default:
+		return []zapcore.Field{f}
+	}
+}
+
+// encodedField redacts a field by encoding it, which may produce several values, e.g. an error and its verbose form
+func (r *Redactor) encodedField(f zapcore.Field) []zapcore.Field {
+	enc := zapcore.NewMapObjectEncoder()
+	f.AddTo(enc)
+
+	fields := make([]zapcore.Field, 0, len(enc.Fields))
+	changed := false
+
+	for k, v := range enc.Fields {
+		redacted, ok := r.value(v)
+		changed = changed || ok
+
+		if s, isString := redacted.(string); isString {
+			fields = append(fields, zap.String(k, s))
+		} else {
+			fields = append(fields, zap.Reflect(k, redacted))
+		}
+	}
+
+	if !changed {
+		return []zapcore.Field{f}
+	}
+
+	return fields
+}
+
+// reflectedField redacts a reflected value by converting it to its JSON representation
+func (r *Redactor) reflectedField(f zapcore.Field) []zapcore.Field {
+	b, err := json.Marshal(f.Interface)
+
+	if err != nil {
+		return []zapcore.Field{f}
+	}
+
+	var v interface{}
+
+	if err := json.Unmarshal(b, &v); err != nil {
+		return []zapcore.Field{f}
+	}
+
+	if redacted, changed := r.value(v); changed {
+		return []zapcore.Field{zap.Reflect(f.Key, redacted)}
+	}
+
+	return []zapcore.Field{f}
+}
+
+// value redacts an encoded value, reporting whether any part of it has been redacted
+func (r *Redactor) value(v interface{}) (interface{}, bool) {
+	switch value := v.(type) {
+	case string:
+		s := r.Scrub(value)
+		return s, s != value
+	case map[string]interface{}:
+		changed := false
+
+		for k, e := range value {
+			if r.SensitiveKey(k) {
+				value[k], changed = config.RedactedValue, true
+				continue
+			}
+
+			var ok bool
+
+			if value[k], ok = r.value(e); ok {
+				changed = true
+			}
+		}
+
+		return value, changed
+	case []interface{}:
+		changed := false
+
+		for i, e := range value {
+			var ok bool
+
+			if value[i], ok = r.value(e); ok {
+				changed = true
+			}
+		}
+
+		return value, changed
+	default:
+		return v, false
+	}
+}
+
+// RedactorFromConfig creates the redactor described under log.redact in the global configuration source
+func RedactorFromConfig() (*Redactor, error) {
+	return RedactorFromSource(config.Global())
+}
+
+// RedactorFromSource creates the redactor described under log.redact in the configuration source,
+// it returns nil if redaction has been disabled
+func RedactorFromSource(src *config.Source) (*Redactor, error) {
+	if src.IsSet(config.LogRedactEnabledKey) && !src.GetBool(config.LogRedactEnabledKey) {
+		return nil, nil
+	}
+
+	r := NewRedactor().
+		Keys(src.GetStringSlice(config.LogRedactKeysKey)...).
+		Headers(src.GetStringSlice(config.LogRedactHeadersKey)...)
+
+	patterns := []string{CreditCardPattern.Name, EmailPattern.Name}
+
+	if src.IsSet(config.LogRedactPatternsKey) {
+		var err error
+
+		if patterns, err = cast.ToStringSliceE(src.Get(config.LogRedactPatternsKey)); err != nil {
+			return nil, fmt.Errorf("%s must be a list of patterns: %w", config.LogRedactPatternsKey, err)
+		}
+	}
+
+	for _, p := range patterns {
+		pattern, err := ParsePattern(p)
+
+		if err != nil {
+			return nil, err
+		}
+
+		r.Patterns(pattern)
+	}
+
+	return r, nil
+}
+
+// redactCore redacts the message and fields of the entries written to the wrapped core
+type redactCore struct {
+	zapcore.Core
+	redactor *Redactor
+}
+
+// NewRedactCore wraps the core so that sensitive values are redacted from the entries written to it. A tee
+// writes every entry to all of its cores whatever their level, wrap each of the cores of a tee rather than
+// the tee, so that each of them only writes the entries allowed by its own level.
+func NewRedactCore(core zapcore.Core, r *Redactor) zapcore.Core {
+	return &redactCore{Core: core, redactor: r}
+}
+
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{Core: c.Core.With(c.redactor.Fields(fields)), redactor: c.redactor}
+}
+
+func (c *redactCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+
+	return ce
+}
+
+func (c *redactCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	ent.Message = c.redactor.Scrub(ent.Message)
+
+	return c.Core.Write(ent, c.redactor.Fields(fields))
+}
+
+// RedactHeaders returns a copy of the headers with the values of sensitive headers redacted by the
+// global logger's redactor, e.g. logger.Named("access").Info("request", zap.Any("headers", logger.RedactHeaders(r.Header))).
+// The headers are returned unchanged if the global logger does not redact entries.
+func RedactHeaders(h http.Header) http.Header {
+	r := loadRoot().redactor
+
+	if r == nil {
+		return h
+	}
+
+	return r.RedactHeaders(h)
+}
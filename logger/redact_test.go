@@ -0,0 +1,147 @@
+package logger
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRedactCore(t *testing.T) {
+	r := NewRedactor().Keys("ssn").Patterns(CreditCardPattern, EmailPattern)
+
+	tests := []struct {
+		name    string
+		message string
+		field   zapcore.Field
+		want    interface{}
+		wantMsg string
+	}{
+		{"Test sensitive key", "login", zap.String("password", "hunter2"), "******", "login"},
+		{"Test sensitive key with punctuation", "login", zap.String("X-Api-Key", "abc"), "******", "login"},
+		{"Test additional key", "lookup", zap.Int("customer_ssn", 123456789), "******", "lookup"},
+		{"Test credit card in value", "paid", zap.String("card", "4111 1111 1111 1111"), "******", "paid"},
+		{"Test number failing checksum", "order", zap.String("order", "1234567890123456"), "1234567890123456", "order"},
+		{"Test email in message", "sent to jane@example.com", zap.Int("count", 1), int64(1), "sent to ******"},
+		{"Test error", "failed", zap.Error(errors.New("unknown user bob@example.org")), "unknown user ******", "failed"},
+		{"Test reflected map", "request", zap.Any("body", map[string]string{"token": "xyz", "name": "bob"}), map[string]interface{}{"token": "******", "name": "bob"}, "request"},
+		{"Test nothing to redact", "hello", zap.String("name", "world"), "world", "hello"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zapcore.DebugLevel)
+			zap.New(NewRedactCore(core, r)).Info(tt.message, tt.field)
+
+			entries := logs.AllUntimed()
+
+			if len(entries) != 1 {
+				t.Fatalf("got %d entries, want 1", len(entries))
+			}
+
+			if entries[0].Message != tt.wantMsg {
+				t.Errorf("message = %q, want %q", entries[0].Message, tt.wantMsg)
+			}
+
+			got := entries[0].ContextMap()[tt.field.Key]
+
+			if !equalValues(got, tt.want) {
+				t.Errorf("%s = %#v, want %#v", tt.field.Key, got, tt.want)
+			}
+		})
+	}
+}
+
+func equalValues(got, want interface{}) bool {
+	gotMap, ok := got.(map[string]interface{})
+
+	if !ok {
+		return got == want
+	}
+
+	wantMap := want.(map[string]interface{})
+
+	if len(gotMap) != len(wantMap) {
+		return false
+	}
+
+	for k, v := range wantMap {
+		if gotMap[k] != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestRedactCoreSinkLevels(t *testing.T) {
+	debug, debugLogs := observer.New(zapcore.DebugLevel)
+	warn, warnLogs := observer.New(zapcore.WarnLevel)
+
+	l, err := NewBuilder().Level(zapcore.DebugLevel).Core(debug).Core(warn).Redact(NewRedactor()).Build()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log := l.With(zap.String("token", "abc"))
+	log.Info("info")
+	log.Warn("warn")
+
+	if debugLogs.Len() != 2 || warnLogs.Len() != 1 {
+		t.Errorf("got %d and %d entries, want 2 and 1", debugLogs.Len(), warnLogs.Len())
+	}
+
+	if got := warnLogs.All()[0].ContextMap()["token"]; got != "******" {
+		t.Errorf("token = %v, want ******", got)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestRedactCoreWriteError(t *testing.T) {
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), zapcore.AddSync(failingWriter{}), zapcore.DebugLevel)
+
+	err := NewRedactCore(core, NewRedactor()).Write(zapcore.Entry{Message: "hello"}, nil)
+
+	if err == nil || err.Error() != "disk full" {
+		t.Errorf("Write() error = %v, want disk full", err)
+	}
+}
+
+func TestRedactHeaders(t *testing.T) {
+	r := NewRedactor().Headers("X-Session").Patterns(EmailPattern)
+
+	h := http.Header{}
+	h.Set("Authorization", "Bearer abc")
+	h.Set("X-Session", "123")
+	h.Set("From", "jane@example.com")
+	h.Set("Accept", "application/json")
+
+	redacted := r.RedactHeaders(h)
+
+	for _, tt := range []struct {
+		header string
+		want   string
+	}{
+		{"Authorization", "******"},
+		{"X-Session", "******"},
+		{"From", "******"},
+		{"Accept", "application/json"},
+	} {
+		if got := redacted.Get(tt.header); got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+
+	if h.Get("Authorization") != "Bearer abc" {
+		t.Error("the original headers have been modified")
+	}
+}
@@ -0,0 +1,306 @@
+package logger
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/birchwood-langham/web-service-bootstrap/config"
+)
+
+// Sampling limits the number of entries with the same level and message written each tick,
+// the first Initial entries are written, then every Thereafter entry. If Thereafter is zero
+// the remaining entries of the tick are dropped.
+type Sampling struct {
+	Initial    int `mapstructure:"initial"`
+	Thereafter int `mapstructure:"thereafter"`
+}
+
+func (s Sampling) enabled() bool {
+	return s.Initial > 0
+}
+
+// SamplingConfig describes how the entries of each level are sampled
+type SamplingConfig struct {
+	// Tick is the period over which entries are counted, it defaults to one second
+	Tick time.Duration
+	// Sampling applies to the levels that are not listed in Levels, entries are not
+	// sampled if Initial is zero
+	Sampling
+	// Levels overrides the sampling of individual levels
+	Levels map[zapcore.Level]Sampling
+}
+
+func (c SamplingConfig) enabled() bool {
+	if c.Sampling.enabled() {
+		return true
+	}
+
+	for _, s := range c.Levels {
+		if s.enabled() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// samplingCore sends the entries of each level to the sampler of the level, entries of levels
+// that are not sampled are sent to the wrapped core
+type samplingCore struct {
+	zapcore.Core
+	samplers map[zapcore.Level]zapcore.Core
+}
+
+// NewSamplingCore wraps the core with zap's sampler, configured separately for each level
+func NewSamplingCore(core zapcore.Core, cfg SamplingConfig) zapcore.Core {
+	tick := cfg.Tick
+
+	if tick <= 0 {
+		tick = time.Second
+	}
+
+	c := &samplingCore{Core: core, samplers: make(map[zapcore.Level]zapcore.Core)}
+
+	for l := zapcore.DebugLevel; l <= zapcore.FatalLevel; l++ {
+		s, ok := cfg.Levels[l]
+
+		if !ok {
+			s = cfg.Sampling
+		}
+
+		if !s.enabled() {
+			continue
+		}
+
+		thereafter := s.Thereafter
+
+		if thereafter <= 0 {
+			// zap's sampler writes every nth entry after the initial entries, an n larger
+			// than any count drops them all
+			thereafter = math.MaxInt32
+		}
+
+		c.samplers[l] = zapcore.NewSamplerWithOptions(core, tick, s.Initial, thereafter)
+	}
+
+	return c
+}
+
+func (c *samplingCore) With(fields []zapcore.Field) zapcore.Core {
+	samplers := make(map[zapcore.Level]zapcore.Core, len(c.samplers))
+
+	for l, s := range c.samplers {
+		samplers[l] = s.With(fields)
+	}
+
+	return &samplingCore{Core: c.Core.With(fields), samplers: samplers}
+}
+
+func (c *samplingCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if s, ok := c.samplers[ent.Level]; ok {
+		return s.Check(ent, ce)
+	}
+
+	return c.Core.Check(ent, ce)
+}
+
+// dedupKey identifies repeated entries, entries with the same message but different fields are not repeats
+type dedupKey struct {
+	level   zapcore.Level
+	logger  string
+	message string
+	fields  uint64
+}
+
+// dedupEntry is the first entry with a key written in the current interval, with its fields, and the
+// number of times it has been repeated since
+type dedupEntry struct {
+	core   zapcore.Core
+	entry  zapcore.Entry
+	fields []zapcore.Field
+	count  int
+}
+
+// dedupState is shared by a dedup core and the cores derived from it with With
+type dedupState struct {
+	lock    sync.Mutex
+	entries map[dedupKey]*dedupEntry
+	ticker  *time.Ticker
+	done    chan struct{}
+	closed  sync.Once
+}
+
+// dedupCore writes the first entry with a given level, message and fields in each interval, later entries
+// are counted and written as a single "repeated N times" entry at the end of the interval
+type dedupCore struct {
+	zapcore.Core
+	state *dedupState
+	// context holds the fields added with With, which are part of the key of each entry
+	context []zapcore.Field
+}
+
+// NewDedupCore wraps the core so that repeated messages are collapsed into a summary written once per
+// interval. The returned closer stops the interval ticker and writes the pending summaries, it must be
+// closed before the wrapped core.
+func NewDedupCore(core zapcore.Core, interval time.Duration) (zapcore.Core, io.Closer) {
+	state := &dedupState{
+		entries: make(map[dedupKey]*dedupEntry),
+		ticker:  time.NewTicker(interval),
+		done:    make(chan struct{}),
+	}
+
+	go state.run()
+
+	return &dedupCore{Core: core, state: state}, state
+}
+
+func (c *dedupCore) With(fields []zapcore.Field) zapcore.Core {
+	context := make([]zapcore.Field, 0, len(c.context)+len(fields))
+	context = append(append(context, c.context...), fields...)
+
+	return &dedupCore{Core: c.Core.With(fields), state: c.state, context: context}
+}
+
+func (c *dedupCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	// entries that stop the application are never held back
+	if ent.Level > zapcore.ErrorLevel || !c.Enabled(ent.Level) {
+		return c.Core.Check(ent, ce)
+	}
+
+	// the fields of an entry are only known when it is written, so repeats are detected by Write
+	return ce.AddCore(ent, c)
+}
+
+func (c *dedupCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	key := dedupKey{level: ent.Level, logger: ent.LoggerName, message: ent.Message, fields: hashFields(c.context, fields)}
+
+	c.state.lock.Lock()
+
+	if e, ok := c.state.entries[key]; ok {
+		e.count++
+		c.state.lock.Unlock()
+
+		return nil
+	}
+
+	c.state.entries[key] = &dedupEntry{core: c.Core, entry: ent, fields: append([]zapcore.Field(nil), fields...)}
+	c.state.lock.Unlock()
+
+	if ce := c.Core.Check(ent, nil); ce != nil {
+		ce.Write(fields...)
+	}
+
+	return nil
+}
+
+// hashFields returns a hash of the keys and values of the fields, which does not depend on their order
+func hashFields(context, fields []zapcore.Field) uint64 {
+	if len(context) == 0 && len(fields) == 0 {
+		return 0
+	}
+
+	enc := zapcore.NewMapObjectEncoder()
+
+	for _, f := range context {
+		f.AddTo(enc)
+	}
+
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+
+	h := fnv.New64a()
+	// fmt prints maps sorted by key
+	_, _ = fmt.Fprint(h, enc.Fields)
+
+	return h.Sum64()
+}
+
+func (s *dedupState) run() {
+	for {
+		select {
+		case <-s.ticker.C:
+			s.flush()
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// flush writes a summary of each repeated entry and starts a new interval
+func (s *dedupState) flush() {
+	s.lock.Lock()
+	entries := s.entries
+	s.entries = make(map[dedupKey]*dedupEntry)
+	s.lock.Unlock()
+
+	for _, e := range entries {
+		if e.count == 0 {
+			continue
+		}
+
+		ent := e.entry
+		ent.Time = time.Now()
+		ent.Message = fmt.Sprintf("%s (repeated %d times)", ent.Message, e.count)
+
+		if ce := e.core.Check(ent, nil); ce != nil {
+			ce.Write(append(e.fields, zap.Int("repeated", e.count))...)
+		}
+	}
+}
+
+// Close stops the interval ticker and writes the summaries of the current interval
+func (s *dedupState) Close() error {
+	s.closed.Do(func() {
+		s.ticker.Stop()
+		close(s.done)
+		s.flush()
+	})
+
+	return nil
+}
+
+// SamplingFromConfig returns the sampling defined under log.sampling in the global configuration source
+func SamplingFromConfig() (SamplingConfig, error) {
+	return SamplingFromSource(config.Global())
+}
+
+// SamplingFromSource returns the sampling defined under log.sampling in the configuration source
+func SamplingFromSource(src *config.Source) (SamplingConfig, error) {
+	cfg := SamplingConfig{
+		Tick: src.GetDuration(config.LogSamplingTickKey),
+		Sampling: Sampling{
+			Initial:    src.GetInt(config.LogSamplingInitialKey),
+			Thereafter: src.GetInt(config.LogSamplingThereafterKey),
+		},
+		Levels: make(map[zapcore.Level]Sampling),
+	}
+
+	for name, raw := range src.GetStringMap(config.LogSamplingLevelsKey) {
+		level, err := ParseLevel(name)
+
+		if err != nil {
+			return cfg, fmt.Errorf("%s: %w", config.LogSamplingLevelsKey, err)
+		}
+
+		// levels inherit the settings they do not override
+		s := cfg.Sampling
+
+		if err := mapstructure.WeakDecode(raw, &s); err != nil {
+			return cfg, fmt.Errorf("%s.%s: %w", config.LogSamplingLevelsKey, strings.ToLower(name), err)
+		}
+
+		cfg.Levels[level] = s
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,100 @@
+package logger
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSamplingCore(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   SamplingConfig
+		level zapcore.Level
+		want  int
+	}{
+		{"Test sampling disabled", SamplingConfig{}, zapcore.InfoLevel, 10},
+		{"Test initial entries only", SamplingConfig{Sampling: Sampling{Initial: 3}}, zapcore.InfoLevel, 3},
+		{"Test every nth entry thereafter", SamplingConfig{Sampling: Sampling{Initial: 2, Thereafter: 4}}, zapcore.InfoLevel, 4},
+		{"Test level override", SamplingConfig{Sampling: Sampling{Initial: 2}, Levels: map[zapcore.Level]Sampling{zapcore.ErrorLevel: {Initial: 5}}}, zapcore.ErrorLevel, 5},
+		{"Test level not sampled", SamplingConfig{Levels: map[zapcore.Level]Sampling{zapcore.DebugLevel: {Initial: 1}}}, zapcore.WarnLevel, 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zapcore.DebugLevel)
+			tt.cfg.Tick = time.Minute
+
+			log := zap.New(NewSamplingCore(core, tt.cfg)).With(zap.String("key", "value"))
+
+			for i := 0; i < 10; i++ {
+				log.Check(tt.level, "flapping").Write()
+			}
+
+			if logs.Len() != tt.want {
+				t.Errorf("got %d entries, want %d", logs.Len(), tt.want)
+			}
+		})
+	}
+}
+
+func TestDedupCore(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	dedup, closer := NewDedupCore(core, time.Hour)
+	log := zap.New(dedup)
+
+	for i := 0; i < 5; i++ {
+		log.Error("connection refused")
+	}
+
+	log.Named("db").Error("connection refused")
+	log.Info("once")
+
+	// entries with different fields are not repeats
+	for i := 0; i < 4; i++ {
+		log.Warn("slow query", zap.String("table", "users"))
+	}
+
+	log.Warn("slow query", zap.String("table", "orders"))
+	// fields added with With are part of the entry
+	log.With(zap.String("table", "orders")).Warn("slow query")
+
+	if err := closer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]int{
+		"connection refused":                    2,
+		"once":                                  1,
+		"connection refused (repeated 4 times)": 1,
+		"slow query":                            2,
+		"slow query (repeated 3 times)":         1,
+		"slow query (repeated 1 times)":         1,
+	}
+
+	got := make(map[string]int)
+
+	for _, e := range logs.AllUntimed() {
+		got[e.Message]++
+	}
+
+	for msg, n := range want {
+		if got[msg] != n {
+			t.Errorf("%q written %d times, want %d", msg, got[msg], n)
+		}
+	}
+
+	if len(got) != len(want) {
+		t.Errorf("got messages %v, want %v", got, want)
+	}
+
+	// the summary keeps the fields of the repeated entry
+	for _, e := range logs.FilterMessage("slow query (repeated 3 times)").AllUntimed() {
+		if got := e.ContextMap(); got["table"] != "users" || got["repeated"] != int64(3) {
+			t.Errorf("summary fields = %v, want table users repeated 3", got)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/cast"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/birchwood-langham/web-service-bootstrap/config"
+)
+
+const (
+	// FormatConsole writes human readable log entries
+	FormatConsole = "console"
+	// FormatJSON writes each log entry as a JSON object
+	FormatJSON = "json"
+	// FormatLogfmt writes each log entry as key=value pairs
+	FormatLogfmt = "logfmt"
+
+	// SinkStdout writes log entries to stdout
+	SinkStdout = "stdout"
+	// SinkStderr writes log entries to stderr
+	SinkStderr = "stderr"
+	// SinkFile writes log entries to a rotating log file
+	SinkFile = "file"
+	// SinkSyslog writes log entries to a syslog server in RFC 5424 format
+	SinkSyslog = "syslog"
+	// SinkJournald writes log entries to the systemd journal using its native protocol
+	SinkJournald = "journald"
+	// SinkNone discards log entries
+	SinkNone = "none"
+)
+
+// SinkConfig describes a destination for log entries, each sink has its own format and
+// minimum level, so that for example JSON can be written to stdout for a log shipper
+// while a coloured console format is written to stderr for developers
+type SinkConfig struct {
+	// Type is one of stdout, stderr, file, syslog, journald or none
+	Type string `mapstructure:"type"`
+	// Format is one of console, json or logfmt, it defaults to log.format, it is not used by the syslog and
+	// journald sinks, which write the fields of an entry separately from its message
+	Format string `mapstructure:"format"`
+	// Level is the minimum level written to the sink, regardless of the application log level,
+	// if it is empty every entry allowed by the application log level is written
+	Level string `mapstructure:"level"`
+	// Color enables coloured levels for the console format
+	Color bool `mapstructure:"color"`
+	// FilePath, MaxSize, MaxBackups, MaxAge and Compress configure the file sink,
+	// they default to the log file settings, e.g. log.filepath
+	FilePath   string `mapstructure:"filepath"`
+	MaxSize    int    `mapstructure:"max-size"`
+	MaxBackups int    `mapstructure:"max-backups"`
+	MaxAge     int    `mapstructure:"max-age"`
+	Compress   bool   `mapstructure:"compress"`
+	// Network and Address locate the syslog server or journald socket. The network is one of udp, tcp or unix,
+	// the syslog sink defaults to the local syslog socket at /dev/log, and the journald sink to the journal's socket.
+	Network string `mapstructure:"network"`
+	Address string `mapstructure:"address"`
+	// Facility is the syslog facility of the entries, e.g. daemon or local0, it defaults to user
+	Facility string `mapstructure:"facility"`
+	// Tag identifies the application in syslog and journald entries, it defaults to the service name
+	Tag string `mapstructure:"tag"`
+}
+
+// defaultSink returns a sink of the given type using the log format and file settings
+// provided in the application settings
+func defaultSink(src *config.Source, sinkType string) SinkConfig {
+	format := src.GetString(config.LogFormatKey)
+
+	if format == "" {
+		format = FormatConsole
+	}
+
+	return SinkConfig{
+		Type:       sinkType,
+		Format:     format,
+		FilePath:   src.GetString(config.LogFilePathKey),
+		MaxSize:    src.GetInt(config.LogFileMaxSize),
+		MaxBackups: src.GetInt(config.LogFileMaxBackups),
+		MaxAge:     src.GetInt(config.LogFileMaxAge),
+		Compress:   src.GetBool(config.LogFileCompress),
+		Tag:        src.GetString(config.ServiceNameKey),
+	}
+}
+
+// SinksFromConfig returns the sinks defined under log.sinks in the global configuration source
+func SinksFromConfig() ([]SinkConfig, error) {
+	return SinksFromSource(config.Global())
+}
+
+// SinksFromSource returns the sinks defined under log.sinks in the configuration source,
+// if no sinks have been defined, log entries are written to the log file and stdout
+func SinksFromSource(src *config.Source) ([]SinkConfig, error) {
+	value := src.Get(config.LogSinksKey)
+
+	if value == nil {
+		return []SinkConfig{defaultSink(src, SinkFile), defaultSink(src, SinkStdout)}, nil
+	}
+
+	raw, err := cast.ToSliceE(value)
+
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a list of sinks: %w", config.LogSinksKey, err)
+	}
+
+	if len(raw) == 0 {
+		return []SinkConfig{defaultSink(src, SinkFile), defaultSink(src, SinkStdout)}, nil
+	}
+
+	sinks := make([]SinkConfig, 0, len(raw))
+
+	for i, r := range raw {
+		s := defaultSink(src, "")
+
+		if err := mapstructure.WeakDecode(r, &s); err != nil {
+			return nil, fmt.Errorf("%s[%d]: %w", config.LogSinksKey, i, err)
+		}
+
+		sinks = append(sinks, s)
+	}
+
+	return sinks, nil
+}
+
+// Encoder creates an encoder for the given format using the default zap configuration,
+// color enables coloured levels for the console format
+func Encoder(format string, color bool) (zapcore.Encoder, error) {
+	cfg := ZapConfig()
+
+	if color {
+		cfg.EncodeLevel = zapcore.CapitalColorLevelEncoder
+	}
+
+	return newEncoder(format, cfg)
+}
+
+func newEncoder(format string, cfg zapcore.EncoderConfig) (zapcore.Encoder, error) {
+	switch strings.ToLower(format) {
+	case FormatConsole, "":
+		return zapcore.NewConsoleEncoder(cfg), nil
+	case FormatJSON:
+		return zapcore.NewJSONEncoder(cfg), nil
+	case FormatLogfmt:
+		return NewLogfmtEncoder(cfg), nil
+	default:
+		return nil, fmt.Errorf("unsupported log format: %s", format)
+	}
+}
+
+// NewSinkCore creates a core that writes to the sink, the returned closer, which may be nil,
+// must be closed once the core is no longer used
+func NewSinkCore(s SinkConfig) (zapcore.Core, io.Closer, error) {
+	level := zapcore.DebugLevel
+
+	if s.Level != "" {
+		var err error
+
+		if level, err = ParseLevel(s.Level); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var writer zapcore.WriteSyncer
+	var closer io.Closer
+
+	switch strings.ToLower(s.Type) {
+	case SinkStdout:
+		writer = zapcore.Lock(os.Stdout)
+	case SinkStderr:
+		writer = zapcore.Lock(os.Stderr)
+	case SinkFile:
+		l := LumberjackLogger(s.FilePath, s.MaxSize, s.MaxBackups, s.MaxAge, s.Compress)
+		writer, closer = zapcore.AddSync(l), l
+	case SinkSyslog:
+		return newSyslogCore(s, level)
+	case SinkJournald:
+		return newJournaldCore(s, level)
+	case SinkNone:
+		return zapcore.NewNopCore(), nil, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported log sink: %s", s.Type)
+	}
+
+	enc, err := Encoder(s.Format, s.Color)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return zapcore.NewCore(enc, writer, level), closer, nil
+}
+
+// newSinkCores creates a core for each of the sinks, returning the closers of the sinks
+func newSinkCores(sinks []SinkConfig) ([]zapcore.Core, []io.Closer, error) {
+	cores := make([]zapcore.Core, 0, len(sinks))
+
+	var closers []io.Closer
+
+	for _, s := range sinks {
+		c, closer, err := NewSinkCore(s)
+
+		if err != nil {
+			closeAll(closers)
+			return nil, nil, err
+		}
+
+		cores = append(cores, c)
+
+		if closer != nil {
+			closers = append(closers, closer)
+		}
+	}
+
+	return cores, closers, nil
+}
+
+func closeAll(closers []io.Closer) {
+	for _, c := range closers {
+		_ = c.Close()
+	}
+}
@@ -0,0 +1,329 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// DefaultSyslogSocket is the local syslog socket used when a syslog sink does not have an address
+const DefaultSyslogSocket = "/dev/log"
+
+const syslogDialTimeout = 5 * time.Second
+
+// syslogSDID is the ID of the RFC 5424 SD-ELEMENT holding the fields of an entry, 32473 is the enterprise
+// number reserved for documentation by RFC 5612
+const syslogSDID = "fields@32473"
+
+var syslogFacilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5, "lpr": 6, "news": 7,
+	"uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19, "local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// parseFacility returns the syslog facility code with the given name, the user facility if the name is empty
+func parseFacility(name string) (int, error) {
+	if name == "" {
+		return syslogFacilities["user"], nil
+	}
+
+	f, ok := syslogFacilities[strings.ToLower(name)]
+
+	if !ok {
+		return 0, fmt.Errorf("unsupported syslog facility: %s", name)
+	}
+
+	return f, nil
+}
+
+// syslogSeverity maps a zap level to a syslog severity
+func syslogSeverity(l zapcore.Level) int {
+	switch l {
+	case zapcore.DebugLevel:
+		return 7 // debug
+	case zapcore.InfoLevel:
+		return 6 // informational
+	case zapcore.WarnLevel:
+		return 4 // warning
+	case zapcore.ErrorLevel:
+		return 3 // error
+	case zapcore.DPanicLevel:
+		return 2 // critical
+	case zapcore.PanicLevel:
+		return 1 // alert
+	default:
+		return 0 // emergency
+	}
+}
+
+// appName returns the tag, or the name of the executable if the tag is empty
+func appName(tag string) string {
+	if tag == "" {
+		tag = filepath.Base(os.Args[0])
+	}
+
+	return tag
+}
+
+// headerField returns the value as a field of an RFC 5424 header, which is made of printable ASCII
+// characters and limited in length, or - if the value is empty
+func headerField(value string, max int) string {
+	value = strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' {
+			return '_'
+		}
+
+		return r
+	}, value)
+
+	if len(value) > max {
+		value = value[:max]
+	}
+
+	if value == "" {
+		return "-"
+	}
+
+	return value
+}
+
+// syslogCore writes entries to a syslog server, the message of each syslog entry is the message of the log
+// entry and its structured fields are written as the parameters of an SD-ELEMENT, nested fields are flattened
+// into dotted names, e.g. [fields@32473 caller="api/server.go:42" user.id="42"]
+type syslogCore struct {
+	zapcore.LevelEnabler
+	context *zapcore.MapObjectEncoder
+	writer  *syslogWriter
+}
+
+func newSyslogCore(s SinkConfig, level zapcore.Level) (zapcore.Core, io.Closer, error) {
+	facility, err := parseFacility(s.Facility)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	network, address := s.Network, s.Address
+
+	if address == "" {
+		network, address = "unix", DefaultSyslogSocket
+	} else if network == "" {
+		network = "udp"
+	}
+
+	switch network {
+	case "udp", "udp4", "udp6", "tcp", "tcp4", "tcp6", "unix":
+	default:
+		return nil, nil, fmt.Errorf("unsupported syslog network: %s", network)
+	}
+
+	hostname, _ := os.Hostname()
+
+	w := &syslogWriter{
+		network:  network,
+		address:  address,
+		facility: facility,
+		hostname: headerField(hostname, 255),
+		appName:  headerField(appName(s.Tag), 48),
+		pid:      os.Getpid(),
+	}
+
+	return &syslogCore{LevelEnabler: level, context: zapcore.NewMapObjectEncoder(), writer: w}, w, nil
+}
+
+func (c *syslogCore) With(fields []zapcore.Field) zapcore.Core {
+	context := zapcore.NewMapObjectEncoder()
+
+	for k, v := range c.context.Fields {
+		context.Fields[k] = v
+	}
+
+	for _, f := range fields {
+		f.AddTo(context)
+	}
+
+	return &syslogCore{LevelEnabler: c.LevelEnabler, context: context, writer: c.writer}
+}
+
+func (c *syslogCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+
+	return ce
+}
+
+func (c *syslogCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+
+	for k, v := range c.context.Fields {
+		enc.Fields[k] = v
+	}
+
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+
+	var sd strings.Builder
+
+	if ent.Caller.Defined {
+		appendSDParam(&sd, "caller", ent.Caller.TrimmedPath())
+	}
+
+	if ent.Stack != "" {
+		appendSDParam(&sd, "stacktrace", ent.Stack)
+	}
+
+	flattenFields("", enc.Fields, func(key, value string) {
+		appendSDParam(&sd, sdName(key), value)
+	})
+
+	data := "-"
+
+	if sd.Len() > 0 {
+		data = "[" + syslogSDID + sd.String() + "]"
+	}
+
+	return c.writer.write(ent, data, ent.Message)
+}
+
+func (c *syslogCore) Sync() error {
+	return nil
+}
+
+// sdName converts a field name into an SD-NAME, which is made of printable ASCII characters other than
+// '=', ']' and '"', and is at most 32 characters long
+func sdName(key string) string {
+	name := headerField(key, 32)
+
+	return strings.Map(func(r rune) rune {
+		if r == '=' || r == ']' || r == '"' {
+			return '_'
+		}
+
+		return r
+	}, name)
+}
+
+// sdValueEscaper escapes the characters that must be escaped in a PARAM-VALUE
+var sdValueEscaper = strings.NewReplacer(`"`, `\"`, `\`, `\\`, `]`, `\]`)
+
+// appendSDParam appends an SD-PARAM, preceded by the space separating it from the SD-ID or previous parameter
+func appendSDParam(sd *strings.Builder, name, value string) {
+	sd.WriteByte(' ')
+	sd.WriteString(name)
+	sd.WriteString(`="`)
+	sd.WriteString(sdValueEscaper.Replace(value))
+	sd.WriteByte('"')
+}
+
+// syslogWriter sends RFC 5424 messages to a syslog server, it connects when the first message is
+// written and reconnects if a message cannot be sent
+type syslogWriter struct {
+	network  string
+	address  string
+	facility int
+	hostname string
+	appName  string
+	pid      int
+
+	lock   sync.Mutex
+	conn   net.Conn
+	stream bool
+}
+
+// format returns the RFC 5424 message for the entry, the logger name is used as the message ID
+func (w *syslogWriter) format(ent zapcore.Entry, data, msg string) string {
+	return fmt.Sprintf("<%d>1 %s %s %s %d %s %s %s",
+		w.facility*8+syslogSeverity(ent.Level),
+		ent.Time.Format("2006-01-02T15:04:05.000000Z07:00"),
+		w.hostname,
+		w.appName,
+		w.pid,
+		headerField(ent.LoggerName, 32),
+		data,
+		msg,
+	)
+}
+
+func (w *syslogWriter) write(ent zapcore.Entry, data, msg string) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	m := w.format(ent, data, msg)
+
+	if w.conn != nil {
+		if err := w.send(m); err == nil {
+			return nil
+		}
+
+		w.close()
+	}
+
+	if err := w.connect(); err != nil {
+		return err
+	}
+
+	return w.send(m)
+}
+
+// send writes the message, messages sent over a stream are framed by octet counting, see RFC 6587
+func (w *syslogWriter) send(msg string) error {
+	if w.stream {
+		msg = fmt.Sprintf("%d %s", len(msg), msg)
+	}
+
+	_, err := io.WriteString(w.conn, msg)
+
+	return err
+}
+
+func (w *syslogWriter) connect() error {
+	var err error
+
+	switch w.network {
+	case "unix":
+		// local syslog daemons usually listen on a datagram socket, but some use a stream socket
+		if w.conn, err = net.DialTimeout("unixgram", w.address, syslogDialTimeout); err == nil {
+			w.stream = false
+			return nil
+		}
+
+		w.conn, err = net.DialTimeout("unix", w.address, syslogDialTimeout)
+		w.stream = true
+	default:
+		w.conn, err = net.DialTimeout(w.network, w.address, syslogDialTimeout)
+		w.stream = strings.HasPrefix(w.network, "tcp")
+	}
+
+	if err != nil {
+		w.conn = nil
+		return fmt.Errorf("could not connect to syslog at %s: %w", w.address, err)
+	}
+
+	return nil
+}
+
+func (w *syslogWriter) close() {
+	if w.conn != nil {
+		_ = w.conn.Close()
+		w.conn = nil
+	}
+}
+
+// Close closes the connection to the syslog server
+func (w *syslogWriter) Close() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.close()
+
+	return nil
+}
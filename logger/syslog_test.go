@@ -0,0 +1,236 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestSyslogSink(t *testing.T) {
+	header := regexp.MustCompile(`^<(\d+)>1 \S+ \S+ myapp \d+ (\S+) (-|\[.*\]) (.*)$`)
+
+	tests := []struct {
+		name     string
+		network  string
+		facility string
+		level    zapcore.Level
+		pri      int
+		msgID    string
+	}{
+		{"Test udp", "udp", "", zapcore.InfoLevel, 1*8 + 6, "db"},
+		{"Test tcp", "tcp", "local0", zapcore.ErrorLevel, 16*8 + 3, "db"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			received, address := listen(t, tt.network)
+
+			core, closer, err := NewSinkCore(SinkConfig{
+				Type:     SinkSyslog,
+				Format:   FormatJSON,
+				Network:  tt.network,
+				Address:  address,
+				Facility: tt.facility,
+				Tag:      "myapp",
+			})
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer closer.Close()
+
+			zap.New(core).Named(tt.msgID).With(zap.Int("user.id", 42)).Check(tt.level, "connected").
+				Write(zap.String("host", "db1"), zap.String("query", `say "hi" [\]`))
+
+			var msg string
+
+			select {
+			case msg = <-received:
+			case <-time.After(5 * time.Second):
+				t.Fatal("no message received")
+			}
+
+			m := header.FindStringSubmatch(msg)
+
+			if m == nil {
+				t.Fatalf("message %q is not in RFC 5424 format", msg)
+			}
+
+			if pri, _ := strconv.Atoi(m[1]); pri != tt.pri {
+				t.Errorf("priority = %d, want %d", pri, tt.pri)
+			}
+
+			if m[2] != tt.msgID {
+				t.Errorf("message ID = %s, want %s", m[2], tt.msgID)
+			}
+
+			if want := `[fields@32473 host="db1" query="say \"hi\" [\\\]" user.id="42"]`; m[3] != want {
+				t.Errorf("structured data = %s, want %s", m[3], want)
+			}
+
+			if m[4] != "connected" {
+				t.Errorf("message = %s, want connected", m[4])
+			}
+		})
+	}
+}
+
+// listen starts a syslog server, returning the messages it receives, tcp messages are framed by octet counting
+func listen(t *testing.T, network string) (chan string, string) {
+	received := make(chan string, 1)
+
+	if network == "udp" {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		t.Cleanup(func() { conn.Close() })
+
+		go func() {
+			buf := make([]byte, 65536)
+
+			if n, _, err := conn.ReadFrom(buf); err == nil {
+				received <- string(buf[:n])
+			}
+		}()
+
+		return received, conn.LocalAddr().String()
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		conn, err := l.Accept()
+
+		if err != nil {
+			return
+		}
+
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		length, err := r.ReadString(' ')
+
+		if err != nil {
+			return
+		}
+
+		n, _ := strconv.Atoi(strings.TrimSpace(length))
+		buf := make([]byte, n)
+
+		if _, err := io.ReadFull(r, buf); err == nil {
+			received <- string(buf)
+		}
+	}()
+
+	return received, l.Addr().String()
+}
+
+func TestJournaldSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "journald")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	socket := filepath.Join(dir, "socket")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+
+	if err != nil {
+		t.Skipf("unix datagram sockets are not supported: %v", err)
+	}
+
+	defer conn.Close()
+
+	core, closer, err := NewSinkCore(SinkConfig{Type: SinkJournald, Address: socket, Tag: "myapp"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer closer.Close()
+
+	zap.New(core, zap.AddCaller()).Named("db").With(zap.Int("user.id", 42)).Warn("query failed", zap.String("query", "select 1\nfrom dual"))
+
+	buf := make([]byte, 65536)
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, err := conn.Read(buf)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fields := parseJournalFields(t, buf[:n])
+
+	for _, tt := range []struct {
+		field string
+		want  string
+	}{
+		{"MESSAGE", "query failed"},
+		{"PRIORITY", "4"},
+		{"SYSLOG_IDENTIFIER", "myapp"},
+		{"LOGGER", "db"},
+		{"USER_ID", "42"},
+		{"QUERY", "select 1\nfrom dual"},
+	} {
+		if got := fields[tt.field]; got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.field, got, tt.want)
+		}
+	}
+
+	if !strings.HasSuffix(fields["CODE_FILE"], "syslog_test.go") {
+		t.Errorf("CODE_FILE = %q, want the test file", fields["CODE_FILE"])
+	}
+}
+
+func parseJournalFields(t *testing.T, b []byte) map[string]string {
+	fields := make(map[string]string)
+
+	for len(b) > 0 {
+		i := bytes.IndexAny(b, "=\n")
+
+		if i < 0 {
+			t.Fatalf("invalid journal field %q", b)
+		}
+
+		name := string(b[:i])
+
+		if b[i] == '=' {
+			end := bytes.IndexByte(b, '\n')
+			fields[name] = string(b[i+1 : end])
+			b = b[end+1:]
+
+			continue
+		}
+
+		n := binary.LittleEndian.Uint64(b[i+1 : i+9])
+		fields[name] = string(b[i+9 : i+9+int(n)])
+		b = b[i+9+int(n)+1:]
+	}
+
+	return fields
+}
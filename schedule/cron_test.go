@@ -0,0 +1,64 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	// a Monday
+	from := time.Date(2020, time.June, 15, 10, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		spec    string
+		want    time.Time
+		wantErr bool
+	}{
+		{"Test every minute", "* * * * *", time.Date(2020, time.June, 15, 10, 8, 0, 0, time.UTC), false},
+		{"Test minute step", "*/15 * * * *", time.Date(2020, time.June, 15, 10, 15, 0, 0, time.UTC), false},
+		{"Test list", "5,40 * * * *", time.Date(2020, time.June, 15, 10, 40, 0, 0, time.UTC), false},
+		{"Test hour range", "0 9-17 * * *", time.Date(2020, time.June, 15, 11, 0, 0, 0, time.UTC), false},
+		{"Test range with step", "0 0-12/6 * * *", time.Date(2020, time.June, 15, 12, 0, 0, 0, time.UTC), false},
+		{"Test value with step", "10/20 * * * *", time.Date(2020, time.June, 15, 10, 10, 0, 0, time.UTC), false},
+		{"Test next day", "30 9 * * *", time.Date(2020, time.June, 16, 9, 30, 0, 0, time.UTC), false},
+		{"Test day of week names", "0 9 * * SAT,SUN", time.Date(2020, time.June, 20, 9, 0, 0, 0, time.UTC), false},
+		{"Test Sunday as 7", "0 0 * * 7", time.Date(2020, time.June, 21, 0, 0, 0, 0, time.UTC), false},
+		{"Test month names", "0 0 1 jan *", time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC), false},
+		{"Test day of month or day of week", "0 0 1 * FRI", time.Date(2020, time.June, 19, 0, 0, 0, 0, time.UTC), false},
+		{"Test leap day", "0 0 29 2 *", time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC), false},
+		{"Test never", "0 0 30 2 *", time.Time{}, false},
+		{"Test hourly", "@hourly", time.Date(2020, time.June, 15, 11, 0, 0, 0, time.UTC), false},
+		{"Test weekly", "@weekly", time.Date(2020, time.June, 21, 0, 0, 0, 0, time.UTC), false},
+		{"Test every", "@every 1m30s", from.Add(90 * time.Second), false},
+		{"Test too few fields", "* * * *", time.Time{}, true},
+		{"Test minute out of range", "60 * * * *", time.Time{}, true},
+		{"Test invalid step", "*/0 * * * *", time.Time{}, true},
+		{"Test reversed range", "0 17-9 * * *", time.Time{}, true},
+		{"Test unknown name", "0 0 * * FUN", time.Time{}, true},
+		{"Test invalid interval", "@every soon", time.Time{}, true},
+		{"Test negative interval", "@every -1m", time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(tt.spec)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if got := s.Next(from); !got.Equal(tt.want) {
+				t.Errorf("Next(%v) = %v, want %v", from, got, tt.want)
+			}
+
+			if got := s.String(); got != tt.spec {
+				t.Errorf("String() = %q, want %q", got, tt.spec)
+			}
+		})
+	}
+}
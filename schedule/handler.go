@@ -0,0 +1,34 @@
+package schedule
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/birchwood-langham/web-service-bootstrap/api"
+)
+
+// Handler returns an http.Handler listing the jobs with their schedule, next run and last result as JSON
+func (s *Scheduler) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		api.RespondWithJSON(w, http.StatusOK, s.Jobs())
+	})
+}
+
+// TriggerHandler returns an http.Handler running the job now, it responds with 202 Accepted once the run has
+// started, 404 Not Found if the job has not been added and 409 Conflict if the job is already running
+func (s *Scheduler) TriggerHandler(name string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := s.Trigger(name)
+
+		switch {
+		case err == nil:
+			api.RespondWithJSON(w, http.StatusAccepted, map[string]string{"job": name, "status": "started"})
+		case errors.Is(err, ErrUnknownJob):
+			api.RespondWithError(w, http.StatusNotFound, err.Error())
+		case errors.Is(err, ErrRunning):
+			api.RespondWithError(w, http.StatusConflict, err.Error())
+		default:
+			api.RespondWithError(w, http.StatusServiceUnavailable, err.Error())
+		}
+	})
+}
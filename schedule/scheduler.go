@@ -0,0 +1,314 @@
+// Package schedule runs jobs on cron expressions or fixed intervals alongside the service
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Func is the function run by a job, it should return once the context is done
+type Func func(ctx context.Context) error
+
+// Options are the options of a job
+type Options struct {
+	// Timeout is how long a run may take before its context is cancelled, 0 does not limit it
+	Timeout time.Duration
+	// Jitter is the maximum of a random delay added to each scheduled run, so that the instances of a service
+	// do not all run the job at the same time
+	Jitter time.Duration
+}
+
+var (
+	// ErrUnknownJob is returned when triggering a job that has not been added
+	ErrUnknownJob = errors.New("unknown job")
+	// ErrRunning is returned when triggering a job whose previous run has not completed
+	ErrRunning = errors.New("the job is already running")
+	// ErrNotStarted is returned when triggering a job before the scheduler has been started
+	ErrNotStarted = errors.New("the scheduler has not been started")
+)
+
+// Result is the outcome of a run of a job
+type Result struct {
+	Trigger  string    `json:"trigger"`
+	Start    time.Time `json:"start"`
+	Duration string    `json:"duration"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// Status is the status of a job reported by the jobs endpoint
+type Status struct {
+	Name     string     `json:"name"`
+	Schedule string     `json:"schedule"`
+	Next     *time.Time `json:"next,omitempty"`
+	Running  bool       `json:"running"`
+	LastRun  *Result    `json:"last_run,omitempty"`
+}
+
+type job struct {
+	name     string
+	schedule Schedule
+	fn       Func
+	opts     Options
+
+	lock    sync.Mutex
+	next    time.Time
+	running bool
+	lastRun *Result
+}
+
+// Scheduler runs jobs on their schedules, a job is not run again while its previous run has not completed
+type Scheduler struct {
+	lock   sync.Mutex
+	jobs   map[string]*job
+	log    *zap.Logger
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New creates a scheduler without jobs
+func New() *Scheduler {
+	return &Scheduler{jobs: make(map[string]*job), log: zap.NewNop()}
+}
+
+// Add adds a job to the scheduler, jobs added once the scheduler has been started are run once it is
+// started again
+func (s *Scheduler) Add(name string, schedule Schedule, fn Func, opts Options) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.jobs[name]; ok {
+		return fmt.Errorf("job %s has already been added", name)
+	}
+
+	s.jobs[name] = &job{name: name, schedule: schedule, fn: fn, opts: opts}
+
+	return nil
+}
+
+// Len returns the number of jobs that have been added
+func (s *Scheduler) Len() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return len(s.jobs)
+}
+
+// Start runs the jobs on their schedules until the scheduler is stopped, the outcome of each run is
+// logged to the logger
+func (s *Scheduler) Start(ctx context.Context, log *zap.Logger) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.log = log
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.ctx = ctx
+
+	for _, j := range s.jobs {
+		s.wg.Add(1)
+
+		go func(j *job) {
+			defer s.wg.Done()
+			s.loop(ctx, j)
+		}(j)
+	}
+}
+
+// Stop stops scheduling the jobs, cancels the runs in progress and waits for them to return until the
+// context is done
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.lock.Lock()
+	cancel := s.cancel
+	s.cancel = nil
+	s.lock.Unlock()
+
+	if cancel == nil {
+		return nil
+	}
+
+	cancel()
+
+	done := make(chan struct{})
+
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("the running jobs did not stop: %w", ctx.Err())
+	}
+}
+
+// Trigger runs a job now, outside its schedule, without waiting for it to complete
+func (s *Scheduler) Trigger(name string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	j, ok := s.jobs[name]
+
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownJob, name)
+	}
+
+	if s.cancel == nil {
+		return ErrNotStarted
+	}
+
+	if !j.begin() {
+		return ErrRunning
+	}
+
+	s.wg.Add(1)
+
+	go func() {
+		defer s.wg.Done()
+		s.run(s.ctx, j, "manual")
+	}()
+
+	return nil
+}
+
+// Jobs returns the status of the jobs ordered by name
+func (s *Scheduler) Jobs() []Status {
+	s.lock.Lock()
+
+	jobs := make([]*job, 0, len(s.jobs))
+
+	for _, j := range s.jobs {
+		jobs = append(jobs, j)
+	}
+
+	s.lock.Unlock()
+
+	sort.Slice(jobs, func(i, k int) bool { return jobs[i].name < jobs[k].name })
+
+	statuses := make([]Status, 0, len(jobs))
+
+	for _, j := range jobs {
+		j.lock.Lock()
+
+		status := Status{Name: j.name, Schedule: j.schedule.String(), Running: j.running}
+
+		if !j.next.IsZero() {
+			next := j.next
+			status.Next = &next
+		}
+
+		if j.lastRun != nil {
+			r := *j.lastRun
+			status.LastRun = &r
+		}
+
+		j.lock.Unlock()
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses
+}
+
+// loop runs the job each time it is due, a run that is due while the previous run has not completed is skipped
+func (s *Scheduler) loop(ctx context.Context, j *job) {
+	for {
+		next := j.schedule.Next(time.Now())
+
+		if next.IsZero() {
+			s.log.Warn("Job will not run again", zap.String("job", j.name), zap.Stringer("schedule", j.schedule))
+			return
+		}
+
+		if j.opts.Jitter > 0 {
+			next = next.Add(time.Duration(rand.Int63n(int64(j.opts.Jitter))))
+		}
+
+		j.lock.Lock()
+		j.next = next
+		j.lock.Unlock()
+
+		timer := time.NewTimer(time.Until(next))
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+
+		if !j.begin() {
+			s.log.Warn("Job skipped, its previous run has not completed", zap.String("job", j.name))
+			continue
+		}
+
+		s.wg.Add(1)
+
+		go func() {
+			defer s.wg.Done()
+			s.run(ctx, j, "schedule")
+		}()
+	}
+}
+
+// begin marks the job as running, it returns false if it is already running
+func (j *job) begin() bool {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	if j.running {
+		return false
+	}
+
+	j.running = true
+
+	return true
+}
+
+// run runs the job, which must have begun, and records and logs its outcome
+func (s *Scheduler) run(ctx context.Context, j *job, trigger string) {
+	if j.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.opts.Timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	err := call(ctx, j.fn)
+	duration := time.Since(start)
+
+	result := &Result{Trigger: trigger, Start: start, Duration: duration.String()}
+
+	fields := []zap.Field{zap.String("job", j.name), zap.String("trigger", trigger), zap.Duration("duration", duration)}
+
+	if err != nil {
+		result.Error = err.Error()
+		s.log.Error("Job failed", append(fields, zap.Error(err))...)
+	} else {
+		s.log.Info("Job completed", fields...)
+	}
+
+	j.lock.Lock()
+	j.running = false
+	j.lastRun = result
+	j.lock.Unlock()
+}
+
+// call calls the function of a job, recovering from a panic so that it is reported as a failed run
+func call(ctx context.Context, fn Func) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return fn(ctx)
+}
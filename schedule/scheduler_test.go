@@ -0,0 +1,118 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type blockingJob struct {
+	release chan struct{}
+
+	lock sync.Mutex
+	runs int
+}
+
+func (j *blockingJob) run(ctx context.Context) error {
+	j.lock.Lock()
+	j.runs++
+	j.lock.Unlock()
+
+	select {
+	case <-j.release:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (j *blockingJob) count() int {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	return j.runs
+}
+
+func TestScheduler(t *testing.T) {
+	s := New()
+	job := &blockingJob{release: make(chan struct{})}
+
+	if err := s.Add("report", Every(10*time.Millisecond), job.run, Options{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Add("report", Every(time.Hour), job.run, Options{}); err == nil {
+		t.Error("Add() with the name of an existing job did not fail")
+	}
+
+	if err := s.Add("timeout", Every(time.Hour), func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, Options{Timeout: 10 * time.Millisecond}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Trigger("report"); !errors.Is(err, ErrNotStarted) {
+		t.Errorf("Trigger() before Start error = %v, want %v", err, ErrNotStarted)
+	}
+
+	s.Start(context.Background(), zap.NewNop())
+
+	// the run is blocked, the runs that are due meanwhile are skipped
+	time.Sleep(50 * time.Millisecond)
+
+	if got := job.count(); got != 1 {
+		t.Errorf("runs while the first run is blocked = %d, want 1", got)
+	}
+
+	tests := []struct {
+		name     string
+		job      string
+		wantCode int
+	}{
+		{"Test trigger running job", "report", http.StatusConflict},
+		{"Test trigger unknown job", "missing", http.StatusNotFound},
+		{"Test trigger job", "timeout", http.StatusAccepted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			s.TriggerHandler(tt.job).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/jobs/"+tt.job+"/run", nil))
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("POST /jobs/%s/run = %d, want %d", tt.job, rec.Code, tt.wantCode)
+			}
+		})
+	}
+
+	close(job.release)
+	time.Sleep(50 * time.Millisecond)
+
+	statuses := s.Jobs()
+
+	if len(statuses) != 2 || statuses[0].Name != "report" || statuses[1].Name != "timeout" {
+		t.Fatalf("Jobs() = %+v, want report and timeout", statuses)
+	}
+
+	if statuses[0].Next == nil || statuses[0].LastRun == nil || statuses[0].LastRun.Error != "" {
+		t.Errorf("report status = %+v, want a next run and a successful last run", statuses[0])
+	}
+
+	if r := statuses[1].LastRun; r == nil || r.Trigger != "manual" || r.Error != context.DeadlineExceeded.Error() {
+		t.Errorf("timeout last run = %+v, want a manual run that timed out", r)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := s.Stop(ctx); err != nil {
+		t.Errorf("Stop() error = %v", err)
+	}
+}
@@ -1,6 +1,10 @@
 package service
 
-import "github.com/birchwood-langham/web-service-bootstrap/api"
+import (
+	"context"
+
+	"github.com/birchwood-langham/web-service-bootstrap/api"
+)
 
 type Application interface {
 	Init() error
@@ -8,3 +12,15 @@ type Application interface {
 	Cleanup() error
 	Properties() Properties
 }
+
+// ContextInitializer is implemented by applications whose initialization can be cancelled, InitContext is
+// called instead of Init with a context that is done once service.startup-timeout has passed
+type ContextInitializer interface {
+	InitContext(ctx context.Context) error
+}
+
+// ContextCleaner is implemented by applications whose clean up can be cancelled, CleanupContext is called
+// instead of Cleanup with a context that is done once service.shutdown-timeout has passed
+type ContextCleaner interface {
+	CleanupContext(ctx context.Context) error
+}
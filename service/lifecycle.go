@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"github.com/birchwood-langham/web-service-bootstrap/config"
+)
+
+// The lifecycle hooks are optional interfaces an Application can implement, the bootstrap detects them and
+// runs them with the timeout set by service.hook-timeout in the following order:
+//
+//   Init, InitializeRoutes, BeforeStart, the ports are bound, AfterStart, the service runs, OnConfigChange
+//   after each reload, BeforeShutdown, the server is shut down, Cleanup
+
+// BeforeStarter is implemented by applications that need to run before the ports are bound, if
+// BeforeStart fails the service is not started
+type BeforeStarter interface {
+	BeforeStart(ctx context.Context) error
+}
+
+// AfterStarter is implemented by applications that need to run once the ports are bound, e.g. to warm
+// caches or register with service discovery, addr is the address the service is listening on
+type AfterStarter interface {
+	AfterStart(ctx context.Context, addr net.Addr) error
+}
+
+// BeforeShutdowner is implemented by applications that need to run before the server is shut down, e.g.
+// to deregister from service discovery before the requests being handled are drained
+type BeforeShutdowner interface {
+	BeforeShutdown(ctx context.Context) error
+}
+
+// ConfigChangeHandler is implemented by applications that need to be told when the configuration has
+// been reloaded, source holds the new configuration
+type ConfigChangeHandler interface {
+	OnConfigChange(ctx context.Context, source *config.Source) error
+}
+
+// Errors aggregates the errors returned while running or stopping the service
+type Errors []error
+
+func (e Errors) Error() string {
+	msgs := make([]string, 0, len(e))
+
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+// Err returns nil if there are no errors, the error if there is only one, or the errors otherwise
+func (e Errors) Err() error {
+	switch len(e) {
+	case 0:
+		return nil
+	case 1:
+		return e[0]
+	default:
+		return e
+	}
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/birchwood-langham/web-service-bootstrap/api"
+)
+
+// Module is a reusable part of an application, such as authentication, auditing or a database, with its own
+// initialization, routes and clean up
+type Module interface {
+	// Name identifies the module, it is used by the modules that depend on it
+	Name() string
+	Init(ctx context.Context) error
+	// InitializeRoutes registers the routes of the module with the router, which serves them under the prefix
+	// the module was added with, the server can be used to add health checks and administrative endpoints
+	InitializeRoutes(server *api.Server, router *mux.Router)
+	Cleanup(ctx context.Context) error
+}
+
+// DependentModule is implemented by modules that depend on other modules, a module is initialized after the
+// modules it depends on and cleaned up before them
+type DependentModule interface {
+	Module
+	DependsOn() []string
+}
+
+type mountedModule struct {
+	module Module
+	prefix string
+}
+
+// Modules is an Application assembled from modules, the modules are initialized in the order of their
+// dependencies, or the order they were added when they do not depend on each other, and cleaned up in the
+// reverse order. Embed it in your application to add routes or hooks of your own.
+type Modules struct {
+	properties  Properties
+	modules     []mountedModule
+	initialized []mountedModule
+}
+
+// NewModules creates an application with the given properties, the modules are added with Add
+func NewModules(properties Properties) *Modules {
+	return &Modules{properties: properties}
+}
+
+// Add adds a module to the application, its routes are served under the path prefix, or at the root
+// if the prefix is empty
+func (m *Modules) Add(module Module, prefix string) *Modules {
+	m.modules = append(m.modules, mountedModule{module: module, prefix: prefix})
+	return m
+}
+
+// Init initializes the modules without a deadline
+func (m *Modules) Init() error {
+	return m.InitContext(context.Background())
+}
+
+// InitContext initializes the modules in the order of their dependencies. If a module cannot be initialized,
+// the modules that have been initialized are cleaned up by CleanupContext, which the bootstrap calls with a
+// context of its own, as the context of the initialization may have expired.
+func (m *Modules) InitContext(ctx context.Context) error {
+	ordered, err := sortModules(m.modules)
+
+	if err != nil {
+		return err
+	}
+
+	for _, mm := range ordered {
+		if err := mm.module.Init(ctx); err != nil {
+			return fmt.Errorf("could not initialize module %s: %w", mm.module.Name(), err)
+		}
+
+		m.initialized = append(m.initialized, mm)
+	}
+
+	return nil
+}
+
+// InitializeRoutes registers the routes of the modules under their prefixes in the order of their dependencies,
+// the modules do not need to have been initialized, so that the routes command can list them without starting
+// the service
+func (m *Modules) InitializeRoutes(server *api.Server) {
+	ordered, err := sortModules(m.modules)
+
+	if err != nil {
+		// the modules cannot be initialized, InitContext reports the error
+		ordered = m.modules
+	}
+
+	for _, mm := range ordered {
+		mm.module.InitializeRoutes(server, server.Mount(mm.prefix))
+	}
+}
+
+// Cleanup cleans up the modules without a deadline
+func (m *Modules) Cleanup() error {
+	return m.CleanupContext(context.Background())
+}
+
+// CleanupContext cleans up the initialized modules in the reverse order they were initialized, every
+// module is cleaned up even if another could not be
+func (m *Modules) CleanupContext(ctx context.Context) error {
+	var errs Errors
+
+	for i := len(m.initialized) - 1; i >= 0; i-- {
+		module := m.initialized[i].module
+
+		if err := module.Cleanup(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("could not clean up module %s: %w", module.Name(), err))
+		}
+	}
+
+	m.initialized = nil
+
+	return errs.Err()
+}
+
+// Properties returns the properties of the application
+func (m *Modules) Properties() Properties {
+	return m.properties
+}
+
+// sortModules orders the modules so that each module follows the modules it depends on, keeping the order
+// the modules were added otherwise
+func sortModules(modules []mountedModule) ([]mountedModule, error) {
+	byName := make(map[string]mountedModule, len(modules))
+
+	for _, mm := range modules {
+		name := mm.module.Name()
+
+		if _, ok := byName[name]; ok {
+			return nil, fmt.Errorf("module %s has been added more than once", name)
+		}
+
+		byName[name] = mm
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+
+	state := make(map[string]int, len(modules))
+	ordered := make([]mountedModule, 0, len(modules))
+
+	var visit func(mm mountedModule, path []string) error
+
+	visit = func(mm mountedModule, path []string) error {
+		name := mm.module.Name()
+		path = append(path, name)
+
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("modules depend on each other: %s", strings.Join(path, " -> "))
+		}
+
+		state[name] = visiting
+
+		if d, ok := mm.module.(DependentModule); ok {
+			for _, dep := range d.DependsOn() {
+				depModule, ok := byName[dep]
+
+				if !ok {
+					return fmt.Errorf("module %s depends on %s, which has not been added", name, dep)
+				}
+
+				if err := visit(depModule, path); err != nil {
+					return err
+				}
+			}
+		}
+
+		state[name] = visited
+		ordered = append(ordered, mm)
+
+		return nil
+	}
+
+	for _, mm := range modules {
+		if err := visit(mm, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return ordered, nil
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/birchwood-langham/web-service-bootstrap/api"
+	"github.com/birchwood-langham/web-service-bootstrap/config"
+)
+
+type testModule struct {
+	name    string
+	deps    []string
+	initErr error
+	calls   *[]string
+}
+
+func (m *testModule) Name() string { return m.name }
+
+func (m *testModule) DependsOn() []string { return m.deps }
+
+func (m *testModule) Init(ctx context.Context) error {
+	*m.calls = append(*m.calls, "init "+m.name)
+	return m.initErr
+}
+
+func (m *testModule) InitializeRoutes(server *api.Server, router *mux.Router) {
+	router.HandleFunc("/"+m.name, func(w http.ResponseWriter, r *http.Request) {
+		api.RespondWithJSON(w, http.StatusOK, m.name)
+	})
+}
+
+func (m *testModule) Cleanup(ctx context.Context) error {
+	*m.calls = append(*m.calls, "cleanup "+m.name)
+	return nil
+}
+
+func TestModules(t *testing.T) {
+	tests := []struct {
+		name      string
+		modules   []testModule
+		wantCalls []string
+		wantErr   string
+	}{
+		{
+			"Test modules are initialized after their dependencies",
+			[]testModule{{name: "audit", deps: []string{"db", "auth"}}, {name: "auth", deps: []string{"db"}}, {name: "db"}},
+			[]string{"init db", "init auth", "init audit", "cleanup audit", "cleanup auth", "cleanup db"},
+			"",
+		},
+		{
+			"Test independent modules keep their order",
+			[]testModule{{name: "b"}, {name: "a"}},
+			[]string{"init b", "init a", "cleanup a", "cleanup b"},
+			"",
+		},
+		{
+			"Test failed init cleans up the initialized modules",
+			[]testModule{{name: "db"}, {name: "auth", deps: []string{"db"}, initErr: errors.New("no keys")}},
+			[]string{"init db", "init auth", "cleanup db"},
+			"could not initialize module auth: no keys",
+		},
+		{
+			"Test missing dependency",
+			[]testModule{{name: "auth", deps: []string{"db"}}},
+			nil,
+			"module auth depends on db, which has not been added",
+		},
+		{
+			"Test dependency cycle",
+			[]testModule{{name: "a", deps: []string{"b"}}, {name: "b", deps: []string{"a"}}},
+			nil,
+			"modules depend on each other: a -> b -> a",
+		},
+		{
+			"Test duplicate module",
+			[]testModule{{name: "db"}, {name: "db"}},
+			nil,
+			"module db has been added more than once",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+
+			m := NewModules(NewProperties("test", "test", "test"))
+
+			for i := range tt.modules {
+				tt.modules[i].calls = &calls
+				m.Add(&tt.modules[i], "")
+			}
+
+			// the modules are cleaned up even if they could not be initialized, as the bootstrap does
+			err := m.InitContext(context.Background())
+
+			if cleanupErr := m.CleanupContext(context.Background()); err == nil {
+				err = cleanupErr
+			}
+
+			if (err != nil) != (tt.wantErr != "") || err != nil && !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(calls, tt.wantCalls) {
+				t.Errorf("calls = %v, want %v", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestModuleRoutePrefix(t *testing.T) {
+	var calls []string
+
+	m := NewModules(NewProperties("test", "test", "test")).
+		Add(&testModule{name: "login", calls: &calls}, "/auth").
+		Add(&testModule{name: "hello", calls: &calls}, "")
+
+	s := api.NewWithSource(config.NewSource(), "localhost", 0, nil)
+	s.Initialize(m.InitializeRoutes)
+
+	// the routes are registered without initializing the modules, as the routes command does
+	if len(calls) != 0 {
+		t.Errorf("calls = %v, want the modules not to be initialized", calls)
+	}
+
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"/auth/login", http.StatusOK},
+		{"/login", http.StatusNotFound},
+		{"/hello", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			s.Router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != tt.want {
+				t.Errorf("GET %s = %d, want %d", tt.path, rec.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/birchwood-langham/web-service-bootstrap/api"
+)
+
+// Worker is a background task of the service, such as a message consumer, that runs alongside the HTTP
+// server. Run should return once the context is done.
+type Worker interface {
+	Run(ctx context.Context) error
+}
+
+// WorkerFunc adapts a function to the Worker interface
+type WorkerFunc func(ctx context.Context) error
+
+// Run calls f(ctx)
+func (f WorkerFunc) Run(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Restart is when a worker that has returned is run again
+type Restart int
+
+const (
+	// RestartOnFailure runs the worker again if it returned an error
+	RestartOnFailure Restart = iota
+	// RestartAlways runs the worker again whenever it returns, until the service is stopped
+	RestartAlways
+	// RestartNever runs the worker once
+	RestartNever
+)
+
+// RestartPolicy is how a worker is restarted once it has returned, the delay before a restart starts at
+// Backoff and doubles after each consecutive failure up to MaxBackoff
+type RestartPolicy struct {
+	Restart Restart
+	// MaxRestarts is the number of consecutive failed runs after which the worker is given up, 0 does not limit them
+	MaxRestarts int
+	// Backoff is the delay before the first restart, 1s if it is not set
+	Backoff time.Duration
+	// MaxBackoff is the longest delay between restarts, 1m if it is not set. A run that lasted longer than
+	// MaxBackoff resets the delay and the number of consecutive failures.
+	MaxBackoff time.Duration
+}
+
+const (
+	defaultBackoff    = time.Second
+	defaultMaxBackoff = time.Minute
+)
+
+// DefaultRestartPolicy restarts a worker that failed, without limiting the number of restarts
+var DefaultRestartPolicy = RestartPolicy{Restart: RestartOnFailure}
+
+type supervisedWorker struct {
+	name   string
+	worker Worker
+	policy RestartPolicy
+
+	lock     sync.Mutex
+	failures int
+	err      error
+	stopped  bool
+}
+
+// Supervisor runs the workers of the service, restarting them according to their policies, until it is stopped
+type Supervisor struct {
+	log     *zap.Logger
+	workers []*supervisedWorker
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// NewSupervisor creates a supervisor that logs the runs of the workers to the logger
+func NewSupervisor(log *zap.Logger) *Supervisor {
+	return &Supervisor{log: log}
+}
+
+// Add adds a worker to the supervisor, workers added once the supervisor has started are not run
+func (s *Supervisor) Add(name string, worker Worker, policy RestartPolicy) {
+	if policy.Backoff <= 0 {
+		policy.Backoff = defaultBackoff
+	}
+
+	if policy.MaxBackoff <= 0 {
+		policy.MaxBackoff = defaultMaxBackoff
+	}
+
+	s.workers = append(s.workers, &supervisedWorker{name: name, worker: worker, policy: policy})
+}
+
+// Start runs each worker in its own goroutine until the context is done or the supervisor is stopped
+func (s *Supervisor) Start(ctx context.Context) {
+	ctx, s.cancel = context.WithCancel(ctx)
+
+	for _, w := range s.workers {
+		s.wg.Add(1)
+
+		go func(w *supervisedWorker) {
+			defer s.wg.Done()
+			s.supervise(ctx, w)
+		}(w)
+	}
+}
+
+// Stop cancels the context of the workers and waits for them to return until the context is done
+func (s *Supervisor) Stop(ctx context.Context) error {
+	if s.cancel == nil {
+		return nil
+	}
+
+	s.cancel()
+
+	done := make(chan struct{})
+
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		var running []string
+
+		for _, w := range s.workers {
+			w.lock.Lock()
+
+			if !w.stopped {
+				running = append(running, w.name)
+			}
+
+			w.lock.Unlock()
+		}
+
+		return fmt.Errorf("workers %s did not stop: %w", strings.Join(running, ", "), ctx.Err())
+	}
+}
+
+// HealthCheck returns a check reporting a worker as unhealthy while it is waiting to be restarted after a
+// failure, or once it has been given up
+func (s *Supervisor) HealthCheck(name string) api.HealthCheck {
+	return func(ctx context.Context) error {
+		for _, w := range s.workers {
+			if w.name == name {
+				w.lock.Lock()
+				defer w.lock.Unlock()
+
+				return w.err
+			}
+		}
+
+		return fmt.Errorf("worker %s has not been added", name)
+	}
+}
+
+func (s *Supervisor) supervise(ctx context.Context, w *supervisedWorker) {
+	log := s.log.With(zap.String("worker", w.name))
+
+	defer func() {
+		w.lock.Lock()
+		w.stopped = true
+		w.lock.Unlock()
+	}()
+
+	backoff := w.policy.Backoff
+
+	for {
+		log.Info("Starting worker")
+
+		w.lock.Lock()
+		w.err = nil
+		w.lock.Unlock()
+
+		started := time.Now()
+		err := run(ctx, w.worker)
+		duration := time.Since(started)
+
+		if ctx.Err() != nil {
+			log.Info("Worker has stopped", zap.Duration("duration", duration))
+			return
+		}
+
+		if duration > w.policy.MaxBackoff {
+			backoff = w.policy.Backoff
+
+			w.lock.Lock()
+			w.failures = 0
+			w.lock.Unlock()
+		}
+
+		if err == nil {
+			w.lock.Lock()
+			w.failures = 0
+			w.lock.Unlock()
+
+			log.Info("Worker has completed", zap.Duration("duration", duration))
+
+			if w.policy.Restart != RestartAlways {
+				return
+			}
+		} else {
+			w.lock.Lock()
+			w.failures++
+			failures := w.failures
+			w.err = err
+
+			giveUp := w.policy.Restart == RestartNever || w.policy.MaxRestarts > 0 && failures > w.policy.MaxRestarts
+
+			if giveUp {
+				w.err = fmt.Errorf("given up after %d failures: %w", failures, err)
+			}
+
+			w.lock.Unlock()
+
+			if giveUp {
+				log.Error("Worker has failed and will not be restarted", zap.Error(err), zap.Int("failures", failures), zap.Duration("duration", duration))
+				return
+			}
+
+			log.Warn("Worker has failed and will be restarted", zap.Error(err), zap.Int("failures", failures), zap.Duration("duration", duration), zap.Duration("backoff", backoff))
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return
+		}
+
+		if err != nil {
+			if backoff *= 2; backoff > w.policy.MaxBackoff {
+				backoff = w.policy.MaxBackoff
+			}
+		}
+	}
+}
+
+// run runs the worker, recovering from a panic so that it is restarted like a worker that failed
+func run(ctx context.Context, w Worker) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return w.Run(ctx)
+}
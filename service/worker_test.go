@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// testWorker returns the results in turn, once they have been used it blocks until it is cancelled
+type testWorker struct {
+	results      []func() error
+	ignoreCancel chan struct{}
+
+	lock sync.Mutex
+	runs int
+}
+
+func (w *testWorker) Run(ctx context.Context) error {
+	w.lock.Lock()
+	run := w.runs
+	w.runs++
+	w.lock.Unlock()
+
+	if run < len(w.results) {
+		return w.results[run]()
+	}
+
+	if w.ignoreCancel != nil {
+		<-w.ignoreCancel
+		return nil
+	}
+
+	<-ctx.Done()
+
+	return ctx.Err()
+}
+
+func (w *testWorker) count() int {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	return w.runs
+}
+
+func TestSupervisor(t *testing.T) {
+	fail := func() error { return errors.New("connection refused") }
+	complete := func() error { return nil }
+	panics := func() error { panic("nil map") }
+
+	tests := []struct {
+		name         string
+		results      []func() error
+		policy       RestartPolicy
+		ignoreCancel bool
+		wantRuns     int
+		wantHealth   string
+		wantStopErr  bool
+	}{
+		{"Test failed worker is restarted", []func() error{fail, fail}, RestartPolicy{Backoff: time.Millisecond}, false, 3, "", false},
+		{"Test completed worker is not restarted", []func() error{complete}, RestartPolicy{Backoff: time.Millisecond}, false, 1, "", false},
+		{"Test completed worker is restarted always", []func() error{complete}, RestartPolicy{Restart: RestartAlways, Backoff: time.Millisecond}, false, 2, "", false},
+		{"Test failed worker is not restarted", []func() error{fail}, RestartPolicy{Restart: RestartNever}, false, 1, "given up after 1 failures: connection refused", false},
+		{"Test worker is given up after max restarts", []func() error{fail, fail, fail, fail}, RestartPolicy{MaxRestarts: 2, Backoff: time.Millisecond}, false, 3, "given up after 3 failures", false},
+		{"Test panic is recovered", []func() error{panics}, RestartPolicy{Backoff: time.Millisecond}, false, 2, "", false},
+		{"Test worker ignoring cancellation", nil, DefaultRestartPolicy, true, 1, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &testWorker{results: tt.results}
+
+			if tt.ignoreCancel {
+				w.ignoreCancel = make(chan struct{})
+				defer close(w.ignoreCancel)
+			}
+
+			s := NewSupervisor(zap.NewNop())
+			s.Add("consumer", w, tt.policy)
+			s.Start(context.Background())
+
+			for i := 0; i < 100 && w.count() < tt.wantRuns; i++ {
+				time.Sleep(10 * time.Millisecond)
+			}
+
+			// allow a worker that should not be restarted to be restarted
+			time.Sleep(20 * time.Millisecond)
+
+			if got := w.count(); got != tt.wantRuns {
+				t.Errorf("runs = %d, want %d", got, tt.wantRuns)
+			}
+
+			err := s.HealthCheck("consumer")(context.Background())
+
+			if (err != nil) != (tt.wantHealth != "") || err != nil && !strings.Contains(err.Error(), tt.wantHealth) {
+				t.Errorf("health = %v, want %q", err, tt.wantHealth)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			if err := s.Stop(ctx); (err != nil) != tt.wantStopErr {
+				t.Errorf("Stop() error = %v, want error %v", err, tt.wantStopErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+// +build go1.18
+
+package version
+
+import (
+	"runtime/debug"
+	"strconv"
+)
+
+// vcsInfo returns the commit, commit time and modified flag recorded in the build information
+func vcsInfo(bi *debug.BuildInfo) (commit, date string, dirty bool) {
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			commit = s.Value
+		case "vcs.time":
+			date = s.Value
+		case "vcs.modified":
+			dirty, _ = strconv.ParseBool(s.Value)
+		}
+	}
+
+	return commit, date, dirty
+}
@@ -0,0 +1,10 @@
+// +build !go1.18
+
+package version
+
+import "runtime/debug"
+
+// vcsInfo returns no values, the build information does not record the vcs information before Go 1.18
+func vcsInfo(*debug.BuildInfo) (commit, date string, dirty bool) {
+	return "", "", false
+}
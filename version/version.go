@@ -0,0 +1,140 @@
+// Package version reports the version of the application binary, either injected when the binary is built:
+//
+//	go build -ldflags "-X github.com/birchwood-langham/web-service-bootstrap/version.Version=1.2.3 \
+//	  -X github.com/birchwood-langham/web-service-bootstrap/version.Commit=$(git rev-parse HEAD) \
+//	  -X github.com/birchwood-langham/web-service-bootstrap/version.Date=$(date -u +%Y-%m-%dT%H:%M:%SZ) \
+//	  -X github.com/birchwood-langham/web-service-bootstrap/version.Dirty=$(test -z "$(git status --porcelain)" && echo false || echo true)"
+//
+// or read from the build information embedded by the Go toolchain when the values have not been injected.
+package version
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+// The build variables are set with -ldflags "-X ...", they are strings as only strings can be set by the linker
+var (
+	// Version is the version of the application, e.g. 1.2.3
+	Version string
+	// Commit is the git commit the application was built from
+	Commit string
+	// Date is the time the application was built, e.g. 2020-06-01T12:00:00Z
+	Date string
+	// Dirty is true if the application was built from a working tree with uncommitted changes
+	Dirty string
+)
+
+// Unknown is the version reported when the version has not been injected and cannot be read from the build information
+const Unknown = "(devel)"
+
+// Info describes the application binary
+type Info struct {
+	Version   string `json:"version" yaml:"version"`
+	Commit    string `json:"commit,omitempty" yaml:"commit,omitempty"`
+	Date      string `json:"date,omitempty" yaml:"date,omitempty"`
+	Dirty     bool   `json:"dirty" yaml:"dirty"`
+	GoVersion string `json:"go_version" yaml:"go_version"`
+	Platform  string `json:"platform" yaml:"platform"`
+}
+
+// Get returns the version information of the application, values injected with -ldflags take precedence over
+// the build information embedded by the Go toolchain
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		Date:      Date,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+
+	info.Dirty, _ = strconv.ParseBool(Dirty)
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		if info.Version == "" && bi.Main.Version != "" {
+			info.Version = bi.Main.Version
+		}
+
+		if info.Commit == "" {
+			// the vcs information is only embedded by Go 1.18 and later
+			commit, date, dirty := vcsInfo(bi)
+			info.Commit = commit
+
+			if info.Date == "" {
+				info.Date = date
+			}
+
+			if Dirty == "" {
+				info.Dirty = dirty
+			}
+		}
+	}
+
+	if info.Version == "" {
+		info.Version = Unknown
+	}
+
+	return info
+}
+
+// String returns the version information on a single line, e.g. 1.2.3 (commit 4f2a9c1, built 2020-06-01T12:00:00Z)
+func (i Info) String() string {
+	var details []string
+
+	if i.Commit != "" {
+		commit := i.Commit
+
+		if len(commit) > 12 {
+			commit = commit[:12]
+		}
+
+		if i.Dirty {
+			commit += "-dirty"
+		}
+
+		details = append(details, "commit "+commit)
+	}
+
+	if i.Date != "" {
+		details = append(details, "built "+i.Date)
+	}
+
+	if len(details) == 0 {
+		return i.Version
+	}
+
+	return fmt.Sprintf("%s (%s)", i.Version, strings.Join(details, ", "))
+}
+
+// Handler returns an http.Handler that responds with the version information as JSON
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(Get())
+	})
+}
+
+// WriteMetric writes the build_info metric in the Prometheus text format, it always has the value 1 and the
+// version information as its labels
+func WriteMetric(w io.Writer) error {
+	info := Get()
+
+	_, err := fmt.Fprintf(w, "# HELP build_info The version information of the application binary\n"+
+		"# TYPE build_info gauge\n"+
+		"build_info{version=%s,commit=%s,date=%s,dirty=\"%t\",go_version=%s,platform=%s} 1\n",
+		label(info.Version), label(info.Commit), label(info.Date), info.Dirty, label(info.GoVersion), label(info.Platform))
+
+	return err
+}
+
+// label quotes a Prometheus label value
+func label(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value) + `"`
+}
@@ -0,0 +1,71 @@
+package version
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestGet(t *testing.T) {
+	defer func(version, commit, date, dirty string) {
+		Version, Commit, Date, Dirty = version, commit, date, dirty
+	}(Version, Commit, Date, Dirty)
+
+	tests := []struct {
+		name   string
+		inject [4]string
+		want   string
+		dirty  bool
+	}{
+		{"Test injected version", [4]string{"1.2.3", "4f2a9c1d8e7b6a5f", "2020-06-01T12:00:00Z", "false"}, "1.2.3 (commit 4f2a9c1d8e7b, built 2020-06-01T12:00:00Z)", false},
+		{"Test injected dirty build", [4]string{"1.2.3", "4f2a9c1", "", "true"}, "1.2.3 (commit 4f2a9c1-dirty)", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Version, Commit, Date, Dirty = tt.inject[0], tt.inject[1], tt.inject[2], tt.inject[3]
+
+			info := Get()
+
+			if got := info.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+
+			if info.Dirty != tt.dirty {
+				t.Errorf("Dirty = %t, want %t", info.Dirty, tt.dirty)
+			}
+		})
+	}
+}
+
+func TestGetWithoutInjectedVersion(t *testing.T) {
+	defer func(version, commit, date, dirty string) {
+		Version, Commit, Date, Dirty = version, commit, date, dirty
+	}(Version, Commit, Date, Dirty)
+
+	Version, Commit, Date, Dirty = "", "", "", ""
+
+	if info := Get(); info.Version == "" || info.GoVersion == "" || info.Platform == "" {
+		t.Errorf("incomplete version information: %+v", info)
+	}
+}
+
+func TestWriteMetric(t *testing.T) {
+	defer func(version, commit string) {
+		Version, Commit = version, commit
+	}(Version, Commit)
+
+	Version, Commit = `1.2.3"beta`, "4f2a9c1"
+
+	var buf bytes.Buffer
+
+	if err := WriteMetric(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"# TYPE build_info gauge\n", `version="1.2.3\"beta"`, `commit="4f2a9c1"`, "} 1\n"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("metric %q does not contain %q", buf.String(), want)
+		}
+	}
+}